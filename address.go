@@ -0,0 +1,25 @@
+package xxl
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+//校验对外地址,需为带scheme的完整URL,可带路径前缀
+func parseAdvertiseAddr(addr string) (string, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", errors.New("invalid advertise address: " + err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("invalid advertise address " + addr + ": scheme must be http or https")
+	}
+	if u.Host == "" {
+		return "", errors.New("invalid advertise address " + addr + ": missing host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", errors.New("invalid advertise address " + addr + ": must not contain userinfo, query or fragment")
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
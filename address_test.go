@@ -0,0 +1,55 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseAdvertiseAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		"http://10.0.0.1:9999":               "http://10.0.0.1:9999",
+		"https://job.svc.cluster.local/xxl/": "https://job.svc.cluster.local/xxl",
+		"http://executor.example.com:80/a/b": "http://executor.example.com:80/a/b",
+	} {
+		got, err := parseAdvertiseAddr(addr)
+		assert.NilError(t, err, addr)
+		assert.Equal(t, want, got)
+	}
+	for _, addr := range []string{"10.0.0.1:9999", "ftp://host", "http://", "http://host/?a=1", "http://u:p@host"} {
+		_, err := parseAdvertiseAddr(addr)
+		assert.Assert(t, err != nil, addr)
+	}
+}
+
+func TestAdvertiseAddrRegistry(t *testing.T) {
+	registry := make(chan *Registry, 1)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &Registry{}
+		_ = json.NewDecoder(r.Body).Decode(req)
+		select {
+		case registry <- req:
+		default:
+		}
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	defer admin.Close()
+
+	e := newExecutor(ServerAddr(admin.URL), ExecutorIp("0.0.0.0"), AdvertiseAddr("https://jobs.example.com/xxl/"))
+	e.Init()
+	assert.Equal(t, "0.0.0.0:9999", e.address)
+	select {
+	case req := <-registry:
+		assert.Equal(t, "https://jobs.example.com/xxl", req.RegistryValue)
+	case <-time.After(2 * time.Second):
+		t.Fatal("registry not received")
+	}
+
+	e = newExecutor(AdvertiseAddr("jobs.example.com:9999"))
+	e.Init()
+	assert.ErrorContains(t, e.Run(), "invalid advertise address")
+}
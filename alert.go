@@ -0,0 +1,218 @@
+package xxl
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+//告警类型
+type AlertKind string
+
+const (
+	AlertFailure     AlertKind = "failure"     //任务执行失败
+	AlertPanic       AlertKind = "panic"       //任务panic
+	AlertTimeout     AlertKind = "timeout"     //任务执行超时
+	AlertConsecutive AlertKind = "consecutive" //同一JobID连续失败
+)
+
+//告警内容
+type Alert struct {
+	Kind     AlertKind `json:"kind"`     // 告警类型
+	Title    string    `json:"title"`    // 标题,按执行器语言
+	Executor string    `json:"executor"` // 执行器名称
+	Handler  string    `json:"handler"`  // 任务名称
+	Pattern  string    `json:"pattern"`  // 匹配到的注册pattern
+	JobID    int64     `json:"jobId"`    // 任务ID
+	LogID    int64     `json:"logId"`    // 本次调度日志ID
+	Code     int64     `json:"code"`     // 执行结果
+	Msg      string    `json:"msg"`      // 执行备注
+	Failures int       `json:"failures"` // 连续失败次数
+	Time     time.Time `json:"time"`     // 告警时间
+}
+
+//告警文本
+func (a *Alert) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[xxl-job] %s\n", a.Title)
+	fmt.Fprintf(&b, "executor: %s\nhandler: %s\njobId: %d\nlogId: %d\n", a.Executor, a.Handler, a.JobID, a.LogID)
+	if a.Failures > 1 {
+		fmt.Fprintf(&b, "failures: %d\n", a.Failures)
+	}
+	fmt.Fprintf(&b, "code: %d\nmsg: %s\ntime: %s", a.Code, a.Msg, a.Time.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
+
+//告警发送
+type Alerter interface {
+	Alert(cxt context.Context, a *Alert) error
+}
+
+//告警策略
+type AlertPolicy struct {
+	Kinds       []AlertKind   // 发送的告警类型,为空时全部发送;只在连续失败时告警可设置为[]AlertKind{AlertConsecutive}
+	Consecutive int           // 同一JobID连续失败(含超时)达到此次数时告警,之后每达到一次倍数再告警,0为不开启
+	Dedup       time.Duration // 相同类型与JobID的告警在此时间内只发送一次,默认5分钟,负数为不去重
+	Limit       int           // 每个Interval内最多发送的告警数,默认30
+	Interval    time.Duration // 限流周期,默认1分钟
+}
+
+//告警发送超时
+var alertTimeout = 10 * time.Second
+
+//记录连续失败次数的JobID数量上限,超过时丢弃任意一个
+const alertFailuresLimit = 10000
+
+//告警管理,通过钩子接收任务结果
+type alerts struct {
+	alerter  Alerter
+	policy   AlertPolicy
+	executor string
+	lang     Lang
+	log      StructuredLogger
+	now      func() time.Time
+	kinds    map[AlertKind]bool //发送的告警类型,nil为全部
+
+	mu       sync.Mutex
+	failures map[int64]int        //[JobID]连续失败次数
+	sent     map[string]time.Time //[类型+pattern+JobID]上次发送时间
+	window   time.Time            //当前限流周期开始时间
+	count    int                  //当前限流周期已发送数
+}
+
+func newAlerts(alerter Alerter, policy AlertPolicy, executor string, lang Lang, log StructuredLogger) *alerts {
+	if policy.Dedup == 0 {
+		policy.Dedup = 5 * time.Minute
+	}
+	if policy.Limit == 0 {
+		policy.Limit = 30
+	}
+	if policy.Interval == 0 {
+		policy.Interval = time.Minute
+	}
+	var kinds map[AlertKind]bool
+	if len(policy.Kinds) > 0 {
+		kinds = make(map[AlertKind]bool, len(policy.Kinds))
+		for _, k := range policy.Kinds {
+			kinds[k] = true
+		}
+	}
+	return &alerts{
+		alerter:  alerter,
+		policy:   policy,
+		executor: executor,
+		lang:     lang,
+		log:      log,
+		now:      time.Now,
+		kinds:    kinds,
+		failures: make(map[int64]int),
+		sent:     make(map[string]time.Time),
+	}
+}
+
+//告警钩子
+func (a *alerts) hooks() Hooks {
+	return Hooks{
+		OnSuccess: func(cxt context.Context, info *RunInfo) {
+			a.mu.Lock()
+			delete(a.failures, info.Param.JobID)
+			a.mu.Unlock()
+		},
+		OnFailure: func(cxt context.Context, info *RunInfo) {
+			kind := AlertFailure
+			if info.Panic {
+				kind = AlertPanic
+			}
+			a.fail(kind, info)
+		},
+		OnTimeout: func(cxt context.Context, info *RunInfo) {
+			a.fail(AlertTimeout, info)
+		},
+	}
+}
+
+//记录失败并告警
+func (a *alerts) fail(kind AlertKind, info *RunInfo) {
+	a.mu.Lock()
+	if _, ok := a.failures[info.Param.JobID]; !ok && len(a.failures) >= alertFailuresLimit {
+		for id := range a.failures {
+			delete(a.failures, id)
+			break
+		}
+	}
+	a.failures[info.Param.JobID]++
+	n := a.failures[info.Param.JobID]
+	a.mu.Unlock()
+	a.fire(kind, info, n)
+	if a.policy.Consecutive > 0 && n%a.policy.Consecutive == 0 {
+		a.fire(AlertConsecutive, info, n)
+	}
+}
+
+//去重、限流后异步发送
+func (a *alerts) fire(kind AlertKind, info *RunInfo, failures int) {
+	if a.kinds != nil && !a.kinds[kind] {
+		return
+	}
+	now := a.now()
+	key := string(kind) + ":" + info.Pattern + ":" + Int64ToStr(info.Param.JobID)
+	a.mu.Lock()
+	if last, ok := a.sent[key]; ok && now.Sub(last) < a.policy.Dedup {
+		a.mu.Unlock()
+		a.log.Debug(a.lang.Text(MsgAlertSuppressed), runFields(info.Param, "msgCode", MsgAlertSuppressed, "kind", kind, "reason", "dedup")...)
+		return
+	}
+	if now.Sub(a.window) >= a.policy.Interval {
+		a.window, a.count = now, 0
+		for k, t := range a.sent {
+			if now.Sub(t) >= a.policy.Dedup {
+				delete(a.sent, k)
+			}
+		}
+	}
+	if a.count >= a.policy.Limit {
+		a.mu.Unlock()
+		a.log.Warn(a.lang.Text(MsgAlertSuppressed), runFields(info.Param, "msgCode", MsgAlertSuppressed, "kind", kind, "reason", "rate_limit")...)
+		return
+	}
+	a.count++
+	a.sent[key] = now
+	a.mu.Unlock()
+
+	alert := &Alert{
+		Kind:     kind,
+		Title:    a.lang.Text(alertTitles[kind]),
+		Executor: a.executor,
+		Handler:  info.Handler,
+		Pattern:  info.Pattern,
+		JobID:    info.Param.JobID,
+		LogID:    info.Param.LogID,
+		Code:     info.Code,
+		Msg:      info.Msg,
+		Failures: failures,
+		Time:     now,
+	}
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				a.log.Error(a.lang.Text(MsgAlertFailed), runFields(info.Param, "msgCode", MsgAlertFailed, "kind", kind, "err", err, "stack", string(debug.Stack()))...)
+			}
+		}()
+		cxt, cancel := context.WithTimeout(context.Background(), alertTimeout)
+		defer cancel()
+		if err := a.alerter.Alert(cxt, alert); err != nil {
+			a.log.Error(a.lang.Text(MsgAlertFailed), runFields(info.Param, "msgCode", MsgAlertFailed, "kind", kind, "err", err)...)
+		}
+	}()
+}
+
+//告警标题
+var alertTitles = map[AlertKind]MsgCode{
+	AlertFailure:     MsgAlertFailure,
+	AlertPanic:       MsgTaskPanic,
+	AlertTimeout:     MsgTaskTimeout,
+	AlertConsecutive: MsgAlertConsecutive,
+}
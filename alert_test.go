@@ -0,0 +1,173 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+//记录告警
+type chanAlerter chan *Alert
+
+func (c chanAlerter) Alert(cxt context.Context, a *Alert) error {
+	c <- a
+	return nil
+}
+
+func TestAlerting(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	bodies := make(chan map[string]interface{}, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer hook.Close()
+
+	e := newExecutor(ServerAddr(admin.URL), Language(LangEn), Alerting(
+		&WebhookAlerter{URL: hook.URL, Format: WebhookDingTalk, Client: hook.Client()},
+		AlertPolicy{Consecutive: 2, Dedup: -1},
+	))
+	e.Init()
+	RegTyped(e, "task.fail", func(cxt context.Context, params string, param *RunReq) Result {
+		return Result{Code: 500, Msg: "bad " + params}
+	})
+	e.RegTask("task.panic", func(cxt context.Context, param *RunReq) string { panic("boom") })
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.fail", ExecutorParams: "a"})
+	<-callbacks
+	waitFor(t, func() bool { return !e.runList.Exists("1") })
+	body := <-bodies
+	assert.Equal(t, "text", body["msgtype"])
+	content := body["text"].(map[string]interface{})["content"].(string)
+	assert.Assert(t, len(content) > 0)
+	assert.Equal(t, "[xxl-job] task failed\nexecutor: golang-jobs\nhandler: task.fail\njobId: 1\nlogId: 1\ncode: 500\nmsg: bad a\ntime: ", content[:len(content)-19])
+
+	trigger(e, &RunReq{JobID: 1, LogID: 2, ExecutorHandler: "task.fail", ExecutorParams: "b"})
+	texts := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		body = <-bodies
+		texts[body["text"].(map[string]interface{})["content"].(string)[:27]] = true
+	}
+	assert.Assert(t, texts["[xxl-job] task failed\nexecu"])
+	assert.Assert(t, texts["[xxl-job] task failed conse"])
+
+	trigger(e, &RunReq{JobID: 2, LogID: 3, ExecutorHandler: "task.panic"})
+	body = <-bodies
+	assert.Equal(t, "[xxl-job] task panic", body["text"].(map[string]interface{})["content"].(string)[:20])
+}
+
+func TestAlertDedupAndLimit(t *testing.T) {
+	c := make(chanAlerter, 10)
+	a := newAlerts(c, AlertPolicy{Dedup: time.Minute, Limit: 2, Interval: time.Minute}, "golang-jobs", LangEn, FromLogger(&logger{}))
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	fail := func(jobID int64) {
+		a.hooks().OnFailure(context.Background(), &RunInfo{Param: &RunReq{JobID: jobID}, Code: 500})
+	}
+
+	fail(1)
+	fail(1) //去重
+	fail(2)
+	fail(3)                                                     //限流
+	got := map[int64]bool{(<-c).JobID: true, (<-c).JobID: true} //异步发送,顺序不定
+	assert.DeepEqual(t, map[int64]bool{1: true, 2: true}, got)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, len(c))
+
+	now = now.Add(time.Minute)
+	fail(1)
+	a1 := <-c
+	assert.Equal(t, int64(1), a1.JobID)
+	assert.Equal(t, 3, a1.Failures)
+
+	a.hooks().OnSuccess(context.Background(), &RunInfo{Param: &RunReq{JobID: 1}, Code: 200})
+	a.hooks().OnTimeout(context.Background(), &RunInfo{Param: &RunReq{JobID: 1}, Code: 502})
+	a2 := <-c
+	assert.Equal(t, AlertTimeout, a2.Kind)
+	assert.Equal(t, 1, a2.Failures)
+	assert.Equal(t, "task execute timeout", a2.Title)
+}
+
+func TestAlertKinds(t *testing.T) {
+	c := make(chanAlerter, 10)
+	a := newAlerts(c, AlertPolicy{Kinds: []AlertKind{AlertConsecutive, AlertPanic}, Consecutive: 3, Dedup: -1}, "golang-jobs", LangEn, FromLogger(&logger{}))
+	fail := func(jobID int64, panicked bool) {
+		a.hooks().OnFailure(context.Background(), &RunInfo{Param: &RunReq{JobID: jobID}, Code: 500, Msg: "[TASK_PANIC] not a panic", Panic: panicked})
+	}
+
+	//只在连续失败3次时告警,panic按RunInfo判断
+	fail(1, false)
+	fail(1, false)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, len(c))
+	fail(1, false)
+	a1 := <-c
+	assert.Equal(t, AlertConsecutive, a1.Kind)
+	assert.Equal(t, 3, a1.Failures)
+	fail(2, true)
+	assert.Equal(t, AlertPanic, (<-c).Kind)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, len(c))
+}
+
+//发送时panic的告警
+type panicAlerter struct{ done chan struct{} }
+
+func (p panicAlerter) Alert(cxt context.Context, a *Alert) error {
+	defer close(p.done)
+	panic("alerter bug")
+}
+
+func TestAlertRecoverAndLimit(t *testing.T) {
+	p := panicAlerter{done: make(chan struct{})}
+	a := newAlerts(p, AlertPolicy{Dedup: -1}, "golang-jobs", LangEn, FromLogger(&logger{}))
+	a.hooks().OnFailure(context.Background(), &RunInfo{Param: &RunReq{JobID: 1}, Code: 500})
+	<-p.done
+
+	//连续失败次数记录有上限
+	a = newAlerts(make(chanAlerter, 1), AlertPolicy{Kinds: []AlertKind{AlertConsecutive}}, "golang-jobs", LangEn, FromLogger(&logger{}))
+	for id := int64(1); id <= alertFailuresLimit+10; id++ {
+		a.hooks().OnTimeout(context.Background(), &RunInfo{Param: &RunReq{JobID: -id}, Code: 502})
+	}
+	assert.Equal(t, alertFailuresLimit, len(a.failures))
+}
+
+func TestWebhookFormats(t *testing.T) {
+	var got []byte
+	reply := `{"code":0}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ioutil.ReadAll(r.Body)
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer srv.Close()
+	alert := &Alert{Kind: AlertFailure, Title: "task failed", Executor: "e", Handler: "h", JobID: 1, LogID: 2, Code: 500, Msg: "bad",
+		Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	text := "[xxl-job] task failed\nexecutor: e\nhandler: h\njobId: 1\nlogId: 2\ncode: 500\nmsg: bad\ntime: 2026-01-01 00:00:00"
+
+	send := func(format WebhookFormat) map[string]interface{} {
+		w := &WebhookAlerter{URL: srv.URL, Format: format, Header: map[string]string{"X-Token": "secret"}, Client: srv.Client()}
+		assert.NilError(t, w.Alert(context.Background(), alert))
+		var body map[string]interface{}
+		assert.NilError(t, json.Unmarshal(got, &body))
+		return body
+	}
+	assert.DeepEqual(t, map[string]interface{}{"msgtype": "text", "text": map[string]interface{}{"content": text}}, send(WebhookDingTalk))
+	assert.DeepEqual(t, map[string]interface{}{"msgtype": "text", "text": map[string]interface{}{"content": text}}, send(WebhookWeCom))
+	assert.DeepEqual(t, map[string]interface{}{"msg_type": "text", "content": map[string]interface{}{"text": text}}, send(WebhookFeishu))
+	body := send(WebhookJSON)
+	assert.Equal(t, "failure", body["kind"])
+	assert.Equal(t, float64(1), body["jobId"])
+
+	reply = `{"errcode":310000,"errmsg":"keywords not in content"}`
+	w := &WebhookAlerter{URL: srv.URL, Format: WebhookDingTalk, Header: map[string]string{"X-Token": "secret"}, Client: srv.Client()}
+	assert.ErrorContains(t, w.Alert(context.Background(), alert), "errcode 310000")
+}
@@ -0,0 +1,88 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+//webhook请求体格式
+type WebhookFormat int
+
+const (
+	WebhookJSON     WebhookFormat = iota //Alert的json
+	WebhookDingTalk                      //钉钉群机器人文本消息
+	WebhookFeishu                        //飞书群机器人文本消息
+	WebhookWeCom                         //企业微信群机器人文本消息
+)
+
+//webhook告警
+type WebhookAlerter struct {
+	URL    string            // webhook地址
+	Format WebhookFormat     // 请求体格式
+	Header map[string]string // 额外请求头
+	Client *http.Client      // 为nil时使用http.DefaultClient
+}
+
+//发送告警,非2xx或机器人返回错误码时返回错误
+func (w *WebhookAlerter) Alert(cxt context.Context, a *Alert) error {
+	body, err := json.Marshal(w.payload(a))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(cxt, "POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	for k, v := range w.Header {
+		request.Header.Set(k, v)
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook status %s: %s", res.Status, data)
+	}
+	//钉钉、企业微信返回errcode,飞书返回code
+	var r struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if w.Format != WebhookJSON && json.Unmarshal(data, &r) == nil {
+		if r.ErrCode != nil && *r.ErrCode != 0 {
+			return fmt.Errorf("webhook errcode %d: %s", *r.ErrCode, r.ErrMsg)
+		}
+		if r.Code != nil && *r.Code != 0 {
+			return fmt.Errorf("webhook code %d: %s", *r.Code, r.Msg)
+		}
+	}
+	return nil
+}
+
+//请求体
+func (w *WebhookAlerter) payload(a *Alert) interface{} {
+	type text struct {
+		Content string `json:"content,omitempty"`
+		Text    string `json:"text,omitempty"`
+	}
+	switch w.Format {
+	case WebhookDingTalk, WebhookWeCom:
+		return map[string]interface{}{"msgtype": "text", "text": text{Content: a.Text()}}
+	case WebhookFeishu:
+		return map[string]interface{}{"msg_type": "text", "content": text{Text: a.Text()}}
+	}
+	return a
+}
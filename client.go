@@ -0,0 +1,178 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/**
+请求调度中心的http客户端,注册、回调、AddJob、StartJob等全部共用:
+	xxl.HTTPClient(client)        自定义客户端(Jar、CheckRedirect等)
+	xxl.Transport(transport)      自定义Transport(代理、连接池)
+	xxl.ClientMiddleware(mw...)   RoundTripper中间件(认证、签名等),按顺序由外到内
+	xxl.AdminTimeout(d)           请求超时
+每个请求都带XXL-JOB-ACCESS-TOKEN请求头,中间件中可以读取;
+ServerAddr中有多个以逗号分隔的调度中心地址时,与java执行器一样按顺序请求,直到一个成功
+*/
+
+//RoundTripper中间件
+type RoundTripperMiddleware func(next http.RoundTripper) http.RoundTripper
+
+//函数形式的RoundTripper
+type RoundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+//创建请求调度中心的客户端
+func (e *executor) newClient() (*http.Client, error) {
+	client := &http.Client{}
+	if e.opts.httpClient != nil {
+		*client = *e.opts.httpClient
+	}
+	if e.opts.Timeout > 0 {
+		client.Timeout = e.opts.Timeout
+	}
+	transport := e.opts.transport
+	if transport == nil {
+		transport = client.Transport
+	}
+	cfg, err := clientTLSConfig(e.opts.adminCAFile, e.opts.adminCertFile, e.opts.adminKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		t, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, errors.New("admin tls requires *http.Transport")
+		}
+		t = t.Clone()
+		t.TLSClientConfig = cfg
+		transport = t
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(e.opts.clientMiddleware) - 1; i >= 0; i-- {
+		transport = e.opts.clientMiddleware[i](transport)
+	}
+	client.Transport = transport
+	return client, nil
+}
+
+//调度中心地址,多个时以逗号分隔
+func parseAdminAddrs(addrs string) []string {
+	var list []string
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			list = append(list, addr)
+		}
+	}
+	return list
+}
+
+//请求一个调度中心,客户端创建失败时返回初始化错误
+func (e *executor) do(cxt context.Context, addr, action, contentType string, body io.Reader) (*http.Response, error) {
+	if e.client == nil {
+		if e.initErr != nil {
+			return nil, e.initErr
+		}
+		return nil, errors.New("xxl-job admin client is not initialized")
+	}
+	request, err := http.NewRequestWithContext(cxt, "POST", addr+action, body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("XXL-JOB-ACCESS-TOKEN", e.opts.AccessToken)
+	injectTrace(cxt, request)
+	return e.client.Do(request)
+}
+
+//调度中心接口错误,http状态码或响应code不为200
+type AdminError struct {
+	Action string // 接口路径
+	Status int    // http状态码
+	Code   int64  // 响应中的code,响应不是json时为0
+	Msg    string // 响应中的msg,响应不是json时为响应内容
+}
+
+func (e *AdminError) Error() string {
+	return fmt.Sprintf("xxl-job admin %s: status %d, code %d, msg %s", e.Action, e.Status, e.Code, e.Msg)
+}
+
+//按顺序请求调度中心,直到一个成功,失败时返回最后一个调度中心的错误
+func (e *executor) call(cxt context.Context, action, contentType string, body []byte) (data []byte, err error) {
+	if len(e.admins) == 0 {
+		return nil, errors.New("xxl-job admin address is empty")
+	}
+	for _, addr := range e.admins {
+		if data, err = e.callAdmin(cxt, addr, action, contentType, body); err == nil || cxt.Err() != nil {
+			return data, err
+		}
+	}
+	return data, err
+}
+
+//请求一个调度中心并读取响应,响应体总会关闭;http状态码或响应code不为200时返回*AdminError与响应内容
+func (e *executor) callAdmin(cxt context.Context, addr, action, contentType string, body []byte) ([]byte, error) {
+	resp, err := e.do(cxt, addr, action, contentType, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	r := &res{}
+	switch {
+	case resp.StatusCode != http.StatusOK:
+		return data, &AdminError{Action: action, Status: resp.StatusCode, Msg: truncate(string(data), 256)}
+	case json.Unmarshal(data, r) != nil:
+		return data, &AdminError{Action: action, Status: resp.StatusCode, Msg: truncate(string(data), 256)}
+	case r.Code != http.StatusOK:
+		msg, ok := r.Msg.(string)
+		if !ok && r.Msg != nil {
+			msg = fmt.Sprint(r.Msg)
+		}
+		return data, &AdminError{Action: action, Status: resp.StatusCode, Code: r.Code, Msg: msg}
+	}
+	return data, nil
+}
+
+//以json格式请求调度中心
+func (e *executor) postJSON(cxt context.Context, action string, v interface{}) ([]byte, error) {
+	param, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return e.call(cxt, action, "application/json;charset=UTF-8", param)
+}
+
+//以表单格式请求调度中心
+func (e *executor) postForm(cxt context.Context, action string, data map[string]interface{}) ([]byte, error) {
+	reqForm := make(url.Values)
+	for k, v := range data {
+		reqForm.Add(k, fmt.Sprint(v))
+	}
+	return e.call(cxt, action, "application/x-www-form-urlencoded", []byte(reqForm.Encode()))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
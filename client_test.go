@@ -0,0 +1,293 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gotest.tools/assert"
+)
+
+func TestClientMiddleware(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]string)
+	)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Get("XXL-JOB-ACCESS-TOKEN") + "|" + r.Header.Get("X-Sign") + "|" + r.Header.Get("Content-Type")
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	defer admin.Close()
+
+	var order []string
+	mw := func(name string) RoundTripperMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				mu.Lock()
+				order = append(order, name+" "+request.URL.Path)
+				mu.Unlock()
+				if name == "sign" {
+					request.Header.Set("X-Sign", "signed:"+request.Header.Get("XXL-JOB-ACCESS-TOKEN"))
+				}
+				return next.RoundTrip(request)
+			})
+		}
+	}
+	e := newExecutor(ServerAddr(admin.URL), AccessToken("secret"), ClientMiddleware(mw("outer"), mw("sign")))
+	e.Init()
+	assert.NilError(t, e.initErr)
+	e.RegTask("task.ok", noopTask("done"))
+
+	_, err := e.AddJob(AddJobInfo{ExecutorHandler: "task.ok"})
+	assert.NilError(t, err)
+	_, err = e.StartJob("1")
+	assert.NilError(t, err)
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ok"})
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		_, ok := seen["/api/callback"]
+		return ok && seen["/api/registry"] != ""
+	})
+	e.registryRemove(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	const jsonType = "application/json;charset=UTF-8"
+	for path, contentType := range map[string]string{
+		"/api/registry":       jsonType,
+		"/api/registryRemove": jsonType,
+		"/api/callback":       jsonType,
+		"/jobinfo/add":        jsonType,
+		"/jobinfo/start":      "application/x-www-form-urlencoded",
+	} {
+		assert.Equal(t, "secret|signed:secret|"+contentType, seen[path], path)
+	}
+	//按顺序由外到内
+	i := indexOf(order, "outer /jobinfo/add")
+	assert.Assert(t, i >= 0)
+	assert.Equal(t, i+1, indexOf(order, "sign /jobinfo/add"))
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestAdminTimeout(t *testing.T) {
+	release := make(chan struct{})
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jobinfo/start" {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	defer admin.Close()
+	defer close(release)
+
+	custom := &http.Client{}
+	e := newExecutor(ServerAddr(admin.URL), HTTPClient(custom), AdminTimeout(50*time.Millisecond))
+	e.Init()
+	start := time.Now()
+	_, err := e.StartJob("1")
+	assert.ErrorContains(t, err, "Timeout")
+	assert.Assert(t, time.Since(start) < time.Second)
+	//不修改传入的client
+	assert.Equal(t, time.Duration(0), custom.Timeout)
+}
+
+func TestTransport(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got *http.Request
+	)
+	transport := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		if request.URL.Path == "/xxl-job-admin/jobinfo/add" {
+			mu.Lock()
+			got = request
+			mu.Unlock()
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"code":200,"msg":null,"content":"7"}`)),
+			Header:     make(http.Header),
+			Request:    request,
+		}, nil
+	})
+	e := newExecutor(ServerAddr("http://admin.invalid/xxl-job-admin"), AccessToken("secret"), Transport(transport))
+	e.Init()
+	body, err := e.AddJobByPostForm(AddJobInfo{ExecutorHandler: "task.a"})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"code":200,"msg":null,"content":"7"}`, string(body))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "secret", got.Header.Get("XXL-JOB-ACCESS-TOKEN"))
+	assert.NilError(t, got.ParseForm())
+	assert.Equal(t, "task.a", got.PostForm.Get("executorHandler"))
+}
+
+func TestAdminTLSRequiresHTTPTransport(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	e := newExecutor(AdminCA(ca.certFile), Transport(RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return nil, context.Canceled
+	})))
+	e.Init()
+	assert.ErrorContains(t, e.initErr, "*http.Transport")
+}
+
+func TestRunAfterInitFailed(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	ca := newTestCert(t, "ca", nil)
+	reg := prometheus.WrapRegistererWith(prometheus.Labels{"app": "demo"}, prometheus.NewRegistry())
+	for _, opt := range []Option{
+		//创建客户端失败
+		Transport(RoundTripperFunc(func(request *http.Request) (*http.Response, error) { return nil, context.Canceled })),
+		//创建客户端之前的步骤失败,仍创建客户端
+		Metrics(reg, nil),
+	} {
+		e := newExecutor(ServerAddr(admin.URL), AdminCA(ca.certFile), opt)
+		e.Init()
+		assert.Assert(t, e.initErr != nil)
+		assert.Equal(t, e.client != nil, strings.Contains(e.initErr.Error(), "gatherer"))
+		e.RegTask("task.a", noopTask("ok"))
+
+		body, _ := json.Marshal(&RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.a"})
+		w := httptest.NewRecorder()
+		e.handler().ServeHTTP(w, httptest.NewRequest("POST", "/run", bytes.NewReader(body)))
+		var c call
+		assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &c))
+		assert.Equal(t, int64(500), c[0].ExecuteResult.Code)
+		assert.Assert(t, strings.Contains(c[0].ExecuteResult.Msg.(string), string(MsgInitFailed)))
+
+		//客户端创建失败时请求调度中心返回初始化错误
+		if e.client == nil {
+			_, err := e.call(context.Background(), "/api/callback", "application/json", nil)
+			assert.Equal(t, e.initErr, err)
+		}
+	}
+	select {
+	case c := <-callbacks:
+		t.Fatalf("unexpected callback %v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+//记录是否关闭的响应体
+type trackedBody struct {
+	io.Reader
+	closed *int32
+}
+
+func (b trackedBody) Close() error {
+	atomic.AddInt32(b.closed, 1)
+	return nil
+}
+
+func TestAdminResponseClosed(t *testing.T) {
+	var opened, closed int32
+	responses := map[string]string{
+		"/jobinfo/add":   `{"code":200,"msg":null}`,
+		"/jobinfo/start": `{"code":500,"msg":"busy"}`,
+		"/jobinfo/stop":  `not json`,
+	}
+	transport := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		body, ok := responses[request.URL.Path]
+		if !ok {
+			return nil, errors.New("unavailable")
+		}
+		atomic.AddInt32(&opened, 1)
+		return &http.Response{StatusCode: http.StatusOK, Body: trackedBody{strings.NewReader(body), &closed}, Header: make(http.Header), Request: request}, nil
+	})
+	e := newExecutor(ServerAddr("http://admin.invalid"), Transport(transport))
+	e.Init()
+
+	_, err := e.AddJob(AddJobInfo{})
+	assert.NilError(t, err)
+	_, err = e.StartJob("1")
+	assert.ErrorContains(t, err, "xxl-job admin /jobinfo/start: status 200, code 500, msg busy")
+	_, err = e.StopJob(1)
+	assert.ErrorContains(t, err, "msg not json")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&opened))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&closed))
+}
+
+func TestCallbackAdminError(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":500,"msg":"The access token is wrong."}`))
+	}))
+	defer admin.Close()
+	errs := make(chan error, 1)
+	e := newExecutor(ServerAddr(admin.URL), WithHooks(Hooks{
+		OnCallback: func(cxt context.Context, info *RunInfo, err error) { errs <- err },
+	}))
+	e.Init()
+	e.RegTask("task.ok", noopTask("done"))
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ok"})
+
+	adminErr := &AdminError{}
+	assert.Assert(t, errors.As(<-errs, &adminErr))
+	assert.Equal(t, "/api/callback", adminErr.Action)
+	assert.Equal(t, int64(500), adminErr.Code)
+	assert.Equal(t, "The access token is wrong.", adminErr.Msg)
+	var msg string
+	waitFor(t, func() bool { _, _, msg = e.heartbeat.get(); return msg != "" })
+	assert.Assert(t, strings.Contains(msg, "The access token is wrong."))
+}
+
+func TestMultipleAdmins(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		hits []string
+	)
+	newAdmin := func(name, body string) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits = append(hits, name+" "+r.URL.Path)
+			mu.Unlock()
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(s.Close)
+		return s
+	}
+	down := newAdmin("down", "")
+	down.Close()
+	busy := newAdmin("busy", `{"code":500,"msg":"busy"}`)
+	ok := newAdmin("ok", `{"code":200,"msg":null}`)
+
+	//按顺序请求,直到一个成功
+	e := newExecutor(ServerAddr(down.URL + ", " + busy.URL + "," + ok.URL))
+	e.Init()
+	_, err := e.StartJob("1")
+	assert.NilError(t, err)
+	mu.Lock()
+	var starts []string
+	for _, h := range hits {
+		if strings.HasSuffix(h, "/jobinfo/start") {
+			starts = append(starts, h)
+		}
+	}
+	mu.Unlock()
+	assert.DeepEqual(t, []string{"busy /jobinfo/start", "ok /jobinfo/start"}, starts)
+
+	//全部失败时返回最后一个错误
+	e = newExecutor(ServerAddr(down.URL + "," + busy.URL))
+	e.Init()
+	_, err = e.StartJob("1")
+	assert.ErrorContains(t, err, "msg busy")
+}
@@ -0,0 +1,154 @@
+//xxl-trigger 不经过调度中心,直接向执行器/run发送调度请求并滚动查看/log日志
+//
+//	go run ./cmd/xxl-trigger -addr http://127.0.0.1:9999 -handler task.test -params "id=1"
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+)
+
+func main() {
+	var (
+		addr     = flag.String("addr", "http://127.0.0.1:9999", "执行器地址")
+		token    = flag.String("token", "", "请求令牌XXL-JOB-ACCESS-TOKEN")
+		handler  = flag.String("handler", "", "任务名称(必填)")
+		params   = flag.String("params", "", "任务参数")
+		jobID    = flag.Int64("job", 1, "任务ID")
+		logID    = flag.Int64("log", 0, "调度日志ID,默认使用当前时间")
+		block    = flag.String("block", "SERIAL_EXECUTION", "阻塞策略:SERIAL_EXECUTION、DISCARD_LATER、COVER_EARLY")
+		timeout  = flag.Int64("timeout", 0, "任务超时时间,单位秒,0为不限制")
+		tail     = flag.Bool("tail", true, "是否滚动查看日志")
+		interval = flag.Duration("interval", time.Second, "查看日志间隔")
+		file     = flag.String("req", "", "从json文件读取RunReq,-为标准输入,设置后忽略任务参数")
+	)
+	flag.Parse()
+
+	now := time.Now()
+	req := &xxl.RunReq{
+		JobID:                 *jobID,
+		ExecutorHandler:       *handler,
+		ExecutorParams:        *params,
+		ExecutorBlockStrategy: *block,
+		ExecutorTimeout:       *timeout,
+		LogID:                 *logID,
+		LogDateTime:           now.UnixMilli(),
+		GlueType:              "BEAN",
+	}
+	if *file != "" {
+		if err := readReq(*file, req); err != nil {
+			fatal(err)
+		}
+	}
+	if req.ExecutorHandler == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if req.LogID == 0 {
+		req.LogID = now.Unix()
+	}
+
+	c := &client{addr: strings.TrimRight(*addr, "/"), token: *token}
+	body, err := c.post("/run", req)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "run jobId=%d logId=%d: %s\n", req.JobID, req.LogID, body)
+	//调度被拒绝时执行器返回回调格式的结果
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		os.Exit(1)
+	}
+	if !*tail {
+		return
+	}
+
+	from := 1
+	for {
+		body, err := c.post("/log", &xxl.LogReq{LogDateTim: req.LogDateTime, LogID: req.LogID, FromLineNum: from})
+		if err != nil {
+			fatal(err)
+		}
+		res := &xxl.LogRes{}
+		if err = json.Unmarshal(body, res); err != nil {
+			fatal(fmt.Errorf("log: %s", body))
+		}
+		if res.Code != 200 {
+			fatal(fmt.Errorf("log: %s", body))
+		}
+		fmt.Print(res.Content.LogContent)
+		if res.Content.ToLineNum >= from {
+			from = res.Content.ToLineNum + 1
+		}
+		if res.Content.IsEnd {
+			if !strings.HasSuffix(res.Content.LogContent, "\n") {
+				fmt.Println()
+			}
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
+
+//读取json格式的RunReq
+func readReq(file string, req *xxl.RunReq) error {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, req)
+}
+
+type client struct {
+	addr  string
+	token string
+}
+
+func (c *client) post(path string, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest("POST", c.addr+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	if c.token != "" {
+		request.Header.Set("XXL-JOB-ACCESS-TOKEN", c.token)
+	}
+	res, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s", path, res.Status, body)
+	}
+	return body, nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
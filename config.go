@@ -0,0 +1,243 @@
+package xxl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/**
+从环境变量或配置文件加载Options,与java执行器使用相同的配置名:
+	xxl.job.admin.addresses          调度中心地址,多个时以逗号分隔,按顺序请求直到一个成功
+	xxl.job.admin.accessToken        请求令牌(兼容xxl.job.accessToken)
+	xxl.job.admin.timeout            接口超时时间,秒或3s格式
+	xxl.job.executor.appname         执行器名称
+	xxl.job.executor.address         注册到调度中心的地址
+	xxl.job.executor.ip              执行器IP
+	xxl.job.executor.port            执行器端口
+	xxl.job.executor.logpath         日志目录
+	xxl.job.executor.logretentiondays 日志保存天数,超过后删除logpath下按日期命名的目录
+也可以使用Options的json名称,如server_addr
+*/
+
+//配置项
+type configField struct {
+	keys []string                         //配置名,第一个为java执行器配置名
+	set  func(o *Options, v string) error //设置到Options
+}
+
+var configFields = []configField{
+	{[]string{"xxl.job.admin.addresses", "server_addr"}, func(o *Options, v string) error {
+		o.ServerAddr = strings.Join(parseAdminAddrs(v), ",")
+		return nil
+	}},
+	{[]string{"xxl.job.admin.accessToken", "xxl.job.accessToken", "access_token"}, func(o *Options, v string) error {
+		o.AccessToken = v
+		return nil
+	}},
+	{[]string{"xxl.job.admin.timeout", "timeout"}, func(o *Options, v string) (err error) {
+		o.Timeout, err = parseTimeout(v)
+		return
+	}},
+	{[]string{"xxl.job.executor.appname", "registry_key"}, func(o *Options, v string) error {
+		o.RegistryKey = v
+		return nil
+	}},
+	{[]string{"xxl.job.executor.address", "advertise_addr"}, func(o *Options, v string) error {
+		o.AdvertiseAddr = v
+		return nil
+	}},
+	{[]string{"xxl.job.executor.ip", "executor_ip"}, func(o *Options, v string) error {
+		o.ExecutorIp = v
+		return nil
+	}},
+	{[]string{"xxl.job.executor.port", "executor_port"}, func(o *Options, v string) error {
+		if _, err := strconv.ParseUint(v, 10, 16); err != nil {
+			return errors.New("must be a port number")
+		}
+		o.ExecutorPort = v
+		return nil
+	}},
+	{[]string{"xxl.job.executor.logpath", "log_dir"}, func(o *Options, v string) error {
+		o.LogDir = v
+		return nil
+	}},
+	{[]string{"xxl.job.executor.logretentiondays", "log_retention_days"}, func(o *Options, v string) (err error) {
+		if o.LogRetentionDays, err = strconv.Atoi(v); err != nil {
+			return errors.New("must be an integer")
+		}
+		return nil
+	}},
+}
+
+//从环境变量加载,变量名为 prefix_ + 配置名大写且以_分隔,如prefix为APP时:
+//	APP_XXL_JOB_ADMIN_ADDRESSES、APP_XXL_JOB_EXECUTOR_APPNAME、APP_SERVER_ADDR
+//prefix为空时为 XXL_JOB_ADMIN_ADDRESSES 等
+func OptionsFromEnv(prefix string) (Options, error) {
+	return loadOptions("env", func(key string) (string, bool) {
+		name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "").Replace(key))
+		if prefix != "" {
+			name = strings.TrimSuffix(prefix, "_") + "_" + name
+		}
+		return os.LookupEnv(name)
+	})
+}
+
+//从配置文件加载,按扩展名支持 .yaml/.yml、.json、.toml、.properties,支持嵌套与xxl.job.admin.addresses扁平两种写法
+func OptionsFromFile(path string) (Options, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Options{}, fmt.Errorf("xxl config %s: %v", path, err)
+	}
+	values := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		//数字保留原文,避免1000000转为1e+06
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = d.Decode(&values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".properties":
+		values, err = parseProperties(data)
+	default:
+		err = errors.New("unsupported config format " + ext + ", use .yaml, .json, .toml or .properties")
+	}
+	if err != nil {
+		return Options{}, fmt.Errorf("xxl config %s: %v", path, err)
+	}
+	flat := make(map[string]string)
+	flattenConfig("", values, flat)
+	return loadOptions(path, func(key string) (string, bool) {
+		v, ok := flat[strings.ToLower(key)]
+		return v, ok
+	})
+}
+
+//按配置项读取并校验
+func loadOptions(source string, lookup func(key string) (string, bool)) (Options, error) {
+	var opts Options
+	for _, f := range configFields {
+		for _, key := range f.keys {
+			v, ok := lookup(key)
+			if !ok {
+				continue
+			}
+			if err := f.set(&opts, strings.TrimSpace(v)); err != nil {
+				return Options{}, fmt.Errorf("xxl config %s: %s=%q: %v", source, key, v, err)
+			}
+			break
+		}
+	}
+	if opts.ServerAddr == "" {
+		return Options{}, fmt.Errorf("xxl config %s: %s is required", source, configFields[0].keys[0])
+	}
+	for _, addr := range parseAdminAddrs(opts.ServerAddr) {
+		if u, err := url.Parse(addr); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Options{}, fmt.Errorf("xxl config %s: %s=%q: must be an http(s) url", source, configFields[0].keys[0], addr)
+		}
+	}
+	return opts, nil
+}
+
+//展开嵌套配置,key统一小写以.连接
+func flattenConfig(prefix string, v interface{}, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, sub := range val {
+			key := strings.ToLower(k)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenConfig(key, sub, out)
+		}
+	case map[interface{}]interface{}:
+		for k, sub := range val {
+			key := strings.ToLower(fmt.Sprint(k))
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenConfig(key, sub, out)
+		}
+	case nil:
+	default:
+		out[prefix] = fmt.Sprint(val)
+	}
+}
+
+//解析java properties文件,只支持key=value与key: value
+func parseProperties(data []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' || text[0] == '!' {
+			continue
+		}
+		i := strings.IndexAny(text, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing '=' in %q", line, text)
+		}
+		values[strings.TrimSpace(text[:i])] = strings.TrimSpace(text[i+1:])
+	}
+	return values, scanner.Err()
+}
+
+//超时时间,纯数字为秒
+func parseTimeout(v string) (time.Duration, error) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.New("must be seconds or a duration such as 3s")
+	}
+	return d, nil
+}
+
+// 使用加载的配置,只覆盖非空字段
+func SetOptions(opts Options) Option {
+	return func(o *Options) {
+		if opts.ServerAddr != "" {
+			o.ServerAddr = opts.ServerAddr
+		}
+		if opts.AccessToken != "" {
+			o.AccessToken = opts.AccessToken
+		}
+		if opts.Timeout != 0 {
+			o.Timeout = opts.Timeout
+		}
+		if opts.ExecutorIp != "" {
+			o.ExecutorIp = opts.ExecutorIp
+		}
+		if opts.ExecutorPort != "" {
+			o.ExecutorPort = opts.ExecutorPort
+		}
+		if opts.AdvertiseAddr != "" {
+			o.AdvertiseAddr = opts.AdvertiseAddr
+		}
+		if opts.RegistryKey != "" {
+			o.RegistryKey = opts.RegistryKey
+		}
+		if opts.LogDir != "" {
+			o.LogDir = opts.LogDir
+		}
+		if opts.LogRetentionDays != 0 {
+			o.LogRetentionDays = opts.LogRetentionDays
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package xxl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gotest.tools/assert"
+)
+
+func TestOptionsFromFile(t *testing.T) {
+	want := Options{
+		ServerAddr:       "http://admin-a:8080/xxl-job-admin",
+		AccessToken:      "token",
+		Timeout:          3 * time.Second,
+		ExecutorPort:     "9998",
+		RegistryKey:      "golang-jobs",
+		LogDir:           "/data/applogs/xxl-job/jobhandler",
+		LogRetentionDays: 30,
+	}
+	files := map[string]string{
+		"app.yaml": `
+xxl:
+  job:
+    admin:
+      addresses: http://admin-a:8080/xxl-job-admin,http://admin-b:8080/xxl-job-admin
+      accessToken: token
+      timeout: 3
+    executor:
+      appname: golang-jobs
+      port: 9998
+      logpath: /data/applogs/xxl-job/jobhandler
+      logretentiondays: 30
+`,
+		"app.json": `{
+  "xxl.job.admin.addresses": "http://admin-a:8080/xxl-job-admin",
+  "xxl.job.accessToken": "token",
+  "timeout": "3s",
+  "xxl": {"job": {"executor": {"appname": "golang-jobs", "port": 9998, "logpath": "/data/applogs/xxl-job/jobhandler", "logretentiondays": 30}}}
+}`,
+		"app.toml": `
+[xxl.job.admin]
+addresses = "http://admin-a:8080/xxl-job-admin"
+accessToken = "token"
+timeout = "3s"
+
+[xxl.job.executor]
+appname = "golang-jobs"
+port = 9998
+logpath = "/data/applogs/xxl-job/jobhandler"
+logretentiondays = 30
+`,
+		"application.properties": `
+# java executor
+xxl.job.admin.addresses=http://admin-a:8080/xxl-job-admin
+xxl.job.accessToken=token
+xxl.job.admin.timeout=3
+xxl.job.executor.appname=golang-jobs
+xxl.job.executor.port=9998
+xxl.job.executor.logpath=/data/applogs/xxl-job/jobhandler
+xxl.job.executor.logretentiondays=30
+`,
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		got, err := OptionsFromFile(path)
+		assert.NilError(t, err, name)
+		want := want
+		if name == "app.yaml" {
+			want.ServerAddr = "http://admin-a:8080/xxl-job-admin,http://admin-b:8080/xxl-job-admin"
+		}
+		assert.DeepEqual(t, want, got, cmpopts.IgnoreUnexported(Options{}))
+	}
+
+	//json中的大数字
+	got, err := OptionsFromFile(write(t, dir, "big.json", `{"server_addr":"http://admin","log_retention_days":1000000}`))
+	assert.NilError(t, err)
+	assert.Equal(t, 1000000, got.LogRetentionDays)
+}
+
+func write(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestOptionsFromFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string { return write(t, dir, name, content) }
+	_, err := OptionsFromFile(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "missing.yaml")
+	_, err = OptionsFromFile(write("app.ini", ""))
+	assert.ErrorContains(t, err, "unsupported config format .ini")
+	_, err = OptionsFromFile(write("empty.yaml", "xxl:\n  job:\n    executor:\n      appname: a\n"))
+	assert.ErrorContains(t, err, "xxl.job.admin.addresses is required")
+	_, err = OptionsFromFile(write("addr.yaml", "server_addr: admin:8080\n"))
+	assert.ErrorContains(t, err, "must be an http(s) url")
+	_, err = OptionsFromFile(write("addrs.yaml", "server_addr: http://admin-a, admin-b:8080\n"))
+	assert.ErrorContains(t, err, `addresses="admin-b:8080": must be an http(s) url`)
+	_, err = OptionsFromFile(write("port.json", `{"server_addr":"http://admin","executor_port":"x"}`))
+	assert.ErrorContains(t, err, `executor_port="x": must be a port number`)
+	_, err = OptionsFromFile(write("bad.json", `{`))
+	assert.ErrorContains(t, err, "bad.json")
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_XXL_JOB_ADMIN_ADDRESSES":   "https://admin/xxl-job-admin",
+		"APP_XXL_JOB_ADMIN_ACCESSTOKEN": "token",
+		"APP_XXL_JOB_EXECUTOR_APPNAME":  "golang-jobs",
+		"APP_EXECUTOR_PORT":             "9998",
+	}
+	for k, v := range env {
+		assert.NilError(t, os.Setenv(k, v))
+		defer os.Unsetenv(k)
+	}
+	got, err := OptionsFromEnv("APP")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, Options{
+		ServerAddr:   "https://admin/xxl-job-admin",
+		AccessToken:  "token",
+		RegistryKey:  "golang-jobs",
+		ExecutorPort: "9998",
+	}, got, cmpopts.IgnoreUnexported(Options{}))
+
+	_, err = OptionsFromEnv("APP_MISSING")
+	assert.ErrorContains(t, err, "xxl.job.admin.addresses is required")
+
+	//只覆盖非空字段
+	o := newOptions(SetOptions(got))
+	assert.Equal(t, "golang-jobs", o.RegistryKey)
+	assert.Equal(t, "9998", o.ExecutorPort)
+	o = newOptions(SetOptions(Options{ServerAddr: "http://admin"}))
+	assert.Equal(t, DefaultExecutorPort, o.ExecutorPort)
+}
@@ -0,0 +1,209 @@
+package xxl
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+/**
+执行器控制台,xxl.Console(true)开启,默认关闭,路径/console/
+必须设置xxl.ConsoleBasicAuth或AccessToken,都未设置时拒绝访问:
+	basic auth   浏览器输入用户名密码
+	AccessToken  页面不含数据,无需认证;页面中输入token后保存在sessionStorage,接口请求头XXL-JOB-ACCESS-TOKEN,不支持url参数传token
+*/
+
+//控制台路径
+const consolePath = "/console/"
+
+//go:embed console.html
+var consoleHTML string
+
+var consoleTemplate = template.Must(template.New("console").Parse(consoleHTML))
+
+//控制台状态
+type ConsoleState struct {
+	Executor    string           `json:"executor"`    // 执行器名称
+	Address     string           `json:"address"`     // 注册地址
+	Registry    RegistryStatus   `json:"registry"`    // 注册心跳状态
+	Handlers    []HandlerInfo    `json:"handlers"`    // 已注册的任务
+	Running     []RunningTask    `json:"running"`     // 正在执行的任务
+	History     []RunRecord      `json:"history"`     // 最近执行记录
+	Concurrency ConcurrencyStats `json:"concurrency"` // 并发使用情况
+}
+
+//注册心跳状态
+type RegistryStatus struct {
+	Registered    bool      `json:"registered"`    // 是否已注册成功
+	Shutdown      bool      `json:"shutdown"`      // 是否正在停止
+	LastHeartbeat time.Time `json:"lastHeartbeat"` // 最近一次心跳成功时间
+	LastFailure   time.Time `json:"lastFailure"`   // 最近一次心跳失败时间
+	LastError     string    `json:"lastError"`     // 最近一次失败原因,成功后清空
+}
+
+//正在执行的任务
+type RunningTask struct {
+	JobID     int64     `json:"jobId"`     // 任务ID
+	LogID     int64     `json:"logId"`     // 调度日志ID
+	Handler   string    `json:"handler"`   // 任务名称
+	Params    string    `json:"params"`    // 任务参数
+	StartTime time.Time `json:"startTime"` // 收到调度的时间
+	ElapsedMs int64     `json:"elapsedMs"` // 已执行毫秒数
+}
+
+//控制台状态
+func (e *executor) consoleState() *ConsoleState {
+	at, failAt, err := e.heartbeat.get()
+	s := &ConsoleState{
+		Executor: e.opts.RegistryKey,
+		Address:  e.registryValue(),
+		Registry: RegistryStatus{
+			Registered:    atomic.LoadInt32(&e.registered) == 1,
+			Shutdown:      atomic.LoadInt32(&e.shutdown) == 1,
+			LastHeartbeat: at,
+			LastFailure:   failAt,
+			LastError:     err,
+		},
+		Handlers:    e.Handlers(),
+		Running:     make([]RunningTask, 0),
+		History:     e.History(HistoryQuery{Limit: 50}),
+		Concurrency: e.limiter.stats(),
+	}
+	now := time.Now()
+	for _, key := range e.runList.Keys() {
+		t := e.runList.Get(key)
+		if t == nil {
+			continue
+		}
+		start := time.UnixMilli(t.StartTime)
+		s.Running = append(s.Running, RunningTask{
+			JobID:     t.Param.JobID,
+			LogID:     t.Param.LogID,
+			Handler:   t.Name,
+			Params:    t.Param.ExecutorParams,
+			StartTime: start,
+			ElapsedMs: now.Sub(start).Milliseconds(),
+		})
+	}
+	sort.Slice(s.Running, func(i, j int) bool { return s.Running[i].StartTime.Before(s.Running[j].StartTime) })
+	return s
+}
+
+//控制台路由
+func (e *executor) consoleRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(consolePath, e.consolePage)
+	mux.HandleFunc(consolePath+"api/state", func(writer http.ResponseWriter, request *http.Request) {
+		writeJSON(writer, http.StatusOK, e.consoleState())
+	})
+	mux.HandleFunc(consolePath+"api/kill", e.consoleKill)
+	mux.HandleFunc(consolePath+"api/log", e.consoleLog)
+	return mux
+}
+
+//控制台页面
+func (e *executor) consolePage(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != consolePath {
+		e.notFound(writer, request)
+		return
+	}
+	l, ok := consoleLabels[e.opts.lang]
+	if !ok {
+		l = consoleLabels[LangZh]
+	}
+	labels, _ := json.Marshal(l)
+	writer.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	_ = consoleTemplate.Execute(writer, map[string]interface{}{
+		"Title":  e.opts.RegistryKey,
+		"Labels": template.JS(labels),
+	})
+}
+
+//终止任务,需POST且带X-XXL-Console请求头,防止跨站请求
+func (e *executor) consoleKill(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost || request.Header.Get("X-XXL-Console") == "" {
+		writeRes(writer, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+	jobID, _ := strconv.ParseInt(request.URL.Query().Get("jobId"), 10, 64)
+	e.mu.Lock()
+	ok := e.kill(jobID)
+	e.mu.Unlock()
+	if !ok {
+		writeRes(writer, http.StatusOK, e.opts.lang.Msg(MsgTaskNotRunning))
+		return
+	}
+	writeJSON(writer, http.StatusOK, &res{Code: http.StatusOK})
+}
+
+//查询日志,参数logId、fromLineNum
+func (e *executor) consoleLog(writer http.ResponseWriter, request *http.Request) {
+	q := request.URL.Query()
+	req := &LogReq{}
+	req.LogID, _ = strconv.ParseInt(q.Get("logId"), 10, 64)
+	req.FromLineNum, _ = strconv.Atoi(q.Get("fromLineNum"))
+	if req.FromLineNum <= 0 {
+		req.FromLineNum = 1
+	}
+	writeJSON(writer, http.StatusOK, e.readLog(req))
+}
+
+//控制台认证,优先basic auth,其次AccessToken(只读请求头),都未设置时拒绝
+func (e *executor) consoleAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch {
+		case e.opts.consoleUser != "":
+			user, pass, _ := request.BasicAuth()
+			if secureEqual(user, e.opts.consoleUser) && secureEqual(pass, e.opts.consolePassword) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+			writer.Header().Set("WWW-Authenticate", `Basic realm="xxl-job executor", charset="UTF-8"`)
+		case e.opts.AccessToken != "":
+			if request.URL.Path == consolePath || secureEqual(request.Header.Get("XXL-JOB-ACCESS-TOKEN"), e.opts.AccessToken) {
+				next.ServeHTTP(writer, request)
+				return
+			}
+		}
+		writeRes(writer, http.StatusUnauthorized, e.opts.lang.Msg(MsgUnauthorized))
+	})
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func writeJSON(writer http.ResponseWriter, code int, v interface{}) {
+	str, _ := json.Marshal(v)
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	writer.WriteHeader(code)
+	_, _ = writer.Write(str)
+}
+
+//控制台文字
+var consoleLabels = map[Lang]map[string]string{
+	LangZh: {
+		"registry": "注册状态", "registered": "已注册", "unregistered": "未注册", "shutdown": "正在停止",
+		"lastHeartbeat": "最近心跳", "lastError": "最近错误", "handlers": "已注册任务", "running": "正在执行",
+		"history": "最近执行", "log": "执行日志", "name": "名称", "description": "描述", "owner": "负责人",
+		"timeout": "超时", "jobId": "任务ID", "logId": "日志ID", "params": "参数", "elapsed": "已执行",
+		"start": "开始时间", "end": "结束时间", "code": "结果", "msg": "备注", "retries": "重试",
+		"kill": "终止", "killConfirm": "确认终止任务", "tail": "查看", "stop": "停止", "none": "无", "token": "请输入AccessToken",
+		"concurrency": "并发",
+	},
+	LangEn: {
+		"registry": "Registry", "registered": "registered", "unregistered": "not registered", "shutdown": "shutting down",
+		"lastHeartbeat": "Last heartbeat", "lastError": "Last error", "handlers": "Handlers", "running": "Running",
+		"history": "Recent runs", "log": "Log", "name": "Name", "description": "Description", "owner": "Owner",
+		"timeout": "Timeout", "jobId": "Job ID", "logId": "Log ID", "params": "Params", "elapsed": "Elapsed",
+		"start": "Start", "end": "End", "code": "Code", "msg": "Message", "retries": "Retries",
+		"kill": "Kill", "killConfirm": "Kill job", "tail": "Tail", "stop": "Stop", "none": "none", "token": "Enter the AccessToken",
+		"concurrency": "Concurrency",
+	},
+}
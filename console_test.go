@@ -0,0 +1,119 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestConsoleDisabled(t *testing.T) {
+	e := newExecutor(AccessToken("secret"))
+	e.Init()
+	w := httptest.NewRecorder()
+	e.handler().ServeHTTP(w, httptest.NewRequest("GET", "/console/", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestConsoleAuth(t *testing.T) {
+	get := func(e *executor, req *http.Request) int {
+		w := httptest.NewRecorder()
+		e.handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	//未设置认证时拒绝
+	e := newExecutor(Console(true))
+	e.Init()
+	assert.Equal(t, http.StatusUnauthorized, get(e, httptest.NewRequest("GET", "/console/", nil)))
+
+	e = newExecutor(Console(true), AccessToken("secret"))
+	e.Init()
+	//页面不含数据,接口只接受请求头
+	assert.Equal(t, http.StatusOK, get(e, httptest.NewRequest("GET", "/console/", nil)))
+	assert.Equal(t, http.StatusUnauthorized, get(e, httptest.NewRequest("GET", "/console/api/state", nil)))
+	assert.Equal(t, http.StatusUnauthorized, get(e, httptest.NewRequest("GET", "/console/api/state?token=secret", nil)))
+	req := httptest.NewRequest("GET", "/console/api/state", nil)
+	req.Header.Set("XXL-JOB-ACCESS-TOKEN", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, get(e, req))
+	req = httptest.NewRequest("GET", "/console/api/state", nil)
+	req.Header.Set("XXL-JOB-ACCESS-TOKEN", "secret")
+	assert.Equal(t, http.StatusOK, get(e, req))
+
+	e = newExecutor(Console(true), AccessToken("secret"), ConsoleBasicAuth("admin", "pw"))
+	e.Init()
+	w := httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/console/", nil)
+	req.Header.Set("XXL-JOB-ACCESS-TOKEN", "secret")
+	e.handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Assert(t, strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic"))
+	req = httptest.NewRequest("GET", "/console/", nil)
+	req.SetBasicAuth("admin", "pw")
+	assert.Equal(t, http.StatusOK, get(e, req))
+}
+
+func TestConsoleAPI(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), Console(true), ConsoleBasicAuth("admin", "pw"), Language(LangEn))
+	e.Init()
+	e.LogHandler(func(req *LogReq) *LogRes {
+		return &LogRes{Code: 200, Content: LogResContent{FromLineNum: req.FromLineNum, ToLineNum: req.FromLineNum, LogContent: "line " + Int64ToStr(req.LogID), IsEnd: true}}
+	})
+	started := make(chan struct{})
+	e.RegTask("task.ctx", func(cxt context.Context, param *RunReq) string {
+		close(started)
+		<-cxt.Done()
+		return "stopped"
+	}, TaskDescription("waits for kill"))
+	serve := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.SetBasicAuth("admin", "pw")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/console/", nil)
+	assert.Equal(t, "text/html;charset=UTF-8", w.Header().Get("Content-Type"))
+	assert.Assert(t, strings.Contains(w.Body.String(), `"running":"Running"`))
+
+	trigger(e, &RunReq{JobID: 7, LogID: 70, ExecutorHandler: "task.ctx", ExecutorParams: "p"})
+	<-started
+	state := &ConsoleState{}
+	assert.NilError(t, json.Unmarshal(serve("GET", "/console/api/state", nil).Body.Bytes(), state))
+	assert.Equal(t, "golang-jobs", state.Executor)
+	assert.Equal(t, 1, len(state.Handlers))
+	assert.Equal(t, "waits for kill", state.Handlers[0].Description)
+	assert.Equal(t, 1, len(state.Running))
+	assert.Equal(t, int64(70), state.Running[0].LogID)
+	assert.Equal(t, "p", state.Running[0].Params)
+	assert.Assert(t, state.Running[0].ElapsedMs >= 0)
+
+	logRes := &LogRes{}
+	assert.NilError(t, json.Unmarshal(serve("GET", "/console/api/log?logId=70", nil).Body.Bytes(), logRes))
+	assert.Equal(t, "line 70", logRes.Content.LogContent)
+	assert.Equal(t, 1, logRes.Content.FromLineNum)
+
+	//kill需要POST与X-XXL-Console请求头
+	assert.Equal(t, http.StatusMethodNotAllowed, serve("GET", "/console/api/kill?jobId=7", nil).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve("POST", "/console/api/kill?jobId=7", nil).Code)
+	w = serve("POST", "/console/api/kill?jobId=7", map[string]string{"X-XXL-Console": "1"})
+	assert.Equal(t, `{"code":200,"msg":null}`, w.Body.String())
+	assert.Equal(t, "stopped", (<-callbacks).ExecuteResult.Msg)
+	w = serve("POST", "/console/api/kill?jobId=7", map[string]string{"X-XXL-Console": "1"})
+	assert.Assert(t, strings.Contains(w.Body.String(), "[TASK_NOT_RUNNING]"))
+
+	waitFor(t, func() bool { return len(e.History(HistoryQuery{})) == 1 })
+	assert.NilError(t, json.Unmarshal(serve("GET", "/console/api/state", nil).Body.Bytes(), state))
+	assert.Equal(t, 0, len(state.Running))
+	assert.Equal(t, 1, len(state.History))
+	assert.Assert(t, state.History[0].Killed)
+}
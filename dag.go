@@ -0,0 +1,318 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/**
+任务依赖(子任务)编排,父任务执行成功后调度中心触发子任务:
+	dag := xxl.NewDAG()
+	dag.Job("etl.extract", task.Extract, xxl.AddJobInfo{JobGroupID: 2, ScheduleType: "CRON", ScheduleConf: "0 0 1 * * ?"})
+	dag.Job("etl.transform", task.Transform, xxl.AddJobInfo{JobGroupID: 2})
+	dag.Job("etl.load", task.Load, xxl.AddJobInfo{JobGroupID: 2})
+	dag.Edge("etl.extract", "etl.transform")
+	dag.Edge("etl.transform", "etl.load")
+	dag.Register(exec)                   //注册任务handler
+	ids, err := dag.Create(cxt, exec)    //在调度中心创建任务,设置ChildJobId
+	runs, err := dag.RunLocal(exec)      //不经过调度中心,在本进程按依赖执行全部任务
+RunLocal执行时子任务通过xxl.TaskParent(cxt)获取触发它的父任务结果,子任务参数为空时使用父任务结果作为参数;
+调度中心触发子任务时不传递父任务的调度信息,无法可靠地对应到父任务的某次执行,
+因此调度中心触发的子任务TaskParent返回false,参数为调度中心任务配置的参数,需要父任务结果时请自行持久化传递
+*/
+
+//任务依赖图,以任务名称(executorHandler)为节点
+type DAG struct {
+	mu      sync.Mutex
+	nodes   []*dagNode
+	byName  map[string]*dagNode
+	errs    []error
+	parents map[int64]ParentResult //[本地触发的LogID]父任务结果,RunLocal执行时设置
+}
+
+type dagNode struct {
+	handler  string
+	fn       TaskFunc
+	info     AddJobInfo
+	parents  []*dagNode
+	children []*dagNode
+}
+
+//触发子任务的父任务结果
+type ParentResult struct {
+	Handler string // 父任务名称
+	Msg     string // 父任务返回的结果
+}
+
+//本地执行记录
+type DAGRun struct {
+	Handler string // 任务名称
+	Parent  string // 触发的父任务,根任务为空
+	Params  string // 执行参数
+	Result  Result // 执行结果
+}
+
+type dagParentKey struct{}
+
+//触发当前子任务的父任务结果,只有RunLocal中由父任务触发时返回true
+func TaskParent(cxt context.Context) (ParentResult, bool) {
+	p, ok := cxt.Value(dagParentKey{}).(ParentResult)
+	return p, ok
+}
+
+//创建任务依赖图
+func NewDAG() *DAG {
+	return &DAG{
+		byName:  make(map[string]*dagNode),
+		parents: make(map[int64]ParentResult),
+	}
+}
+
+//增加任务,info为在调度中心创建任务的参数,ExecutorHandler与ChildJobId由DAG设置;
+//fn为nil时不注册handler,任务由其他执行器执行
+func (d *DAG) Job(handler string, fn TaskFunc, info AddJobInfo) *DAG {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.byName[handler]; ok {
+		d.errs = append(d.errs, fmt.Errorf("xxl dag: duplicate job %s", handler))
+		return d
+	}
+	n := &dagNode{handler: handler, fn: fn, info: info}
+	d.nodes = append(d.nodes, n)
+	d.byName[handler] = n
+	return d
+}
+
+//增加依赖,parent执行成功后触发children
+func (d *DAG) Edge(parent string, children ...string) *DAG {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p, ok := d.byName[parent]
+	if !ok {
+		d.errs = append(d.errs, fmt.Errorf("xxl dag: unknown job %s", parent))
+		return d
+	}
+	for _, child := range children {
+		c, ok := d.byName[child]
+		if !ok {
+			d.errs = append(d.errs, fmt.Errorf("xxl dag: unknown job %s", child))
+			continue
+		}
+		p.children = append(p.children, c)
+		c.parents = append(c.parents, p)
+	}
+	return d
+}
+
+//校验任务依赖图,返回重复任务、未知任务与循环依赖错误
+func (d *DAG) Validate() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.sort()
+	return err
+}
+
+//拓扑排序,父任务在前;存在循环依赖时返回错误
+func (d *DAG) sort() ([]*dagNode, error) {
+	if len(d.errs) > 0 {
+		return nil, errors.Join(d.errs...)
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*dagNode]int, len(d.nodes))
+	order := make([]*dagNode, 0, len(d.nodes))
+	var path []string
+	var visit func(n *dagNode) error
+	visit = func(n *dagNode) error {
+		switch state[n] {
+		case visiting:
+			for i, h := range path {
+				if h == n.handler {
+					return fmt.Errorf("xxl dag: cycle %s", strings.Join(append(path[i:], n.handler), " -> "))
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[n] = visiting
+		path = append(path, n.handler)
+		for _, c := range n.children {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = visited
+		order = append(order, n)
+		return nil
+	}
+	for _, n := range d.nodes {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+	//后序遍历得到子任务在前的顺序,反转为父任务在前
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order, nil
+}
+
+//注册全部任务handler
+func (d *DAG) Register(e Executor, opts ...TaskOption) {
+	d.mu.Lock()
+	nodes := append([]*dagNode(nil), d.nodes...)
+	d.mu.Unlock()
+	for _, n := range nodes {
+		if n.fn != nil {
+			e.RegTask(n.handler, d.wrap(n), opts...)
+		}
+	}
+}
+
+//包装任务函数,RunLocal触发的子任务按LogID取出父任务结果
+func (d *DAG) wrap(n *dagNode) TaskFunc {
+	return func(cxt context.Context, param *RunReq) string {
+		d.mu.Lock()
+		p, ok := d.parents[param.LogID]
+		d.mu.Unlock()
+		if ok {
+			cxt = context.WithValue(cxt, dagParentKey{}, p)
+		}
+		return n.fn(cxt, param)
+	}
+}
+
+//在调度中心创建全部任务,子任务先创建,父任务的ChildJobId为子任务ID;
+//返回任务名称到调度中心任务ID,失败时返回已创建的任务
+func (d *DAG) Create(cxt context.Context, e Executor) (map[string]int, error) {
+	d.mu.Lock()
+	order, err := d.sort()
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+		info := n.info
+		info.ExecutorHandler = n.handler
+		if info.GlueType == "" {
+			info.GlueType = "BEAN"
+		}
+		if info.ScheduleType == "" && len(n.parents) > 0 {
+			info.ScheduleType = "NONE" //只由父任务触发
+		}
+		children := make([]int, 0, len(n.children))
+		for _, c := range n.children {
+			children = append(children, ids[c.handler])
+		}
+		info.ChildJobId = FormatChildJobIDs(children)
+		body, err := e.AddJobByPostFormContext(cxt, info)
+		if err != nil {
+			return ids, fmt.Errorf("xxl dag: create %s: %w", n.handler, err)
+		}
+		res := &RespAddJob{}
+		_ = json.Unmarshal(body, res)
+		id, err := strconv.Atoi(res.Content)
+		if err != nil {
+			return ids, fmt.Errorf("xxl dag: create %s: invalid job id in %s", n.handler, body)
+		}
+		ids[n.handler] = id
+	}
+	return ids, nil
+}
+
+//本地模拟:不经过调度中心,通过TriggerLocal按依赖执行全部任务,父任务成功(200)后执行子任务;
+//与调度中心一样,有多个父任务的子任务每个父任务成功后都会执行一次。需先调用Register
+func (d *DAG) RunLocal(e Executor) ([]DAGRun, error) {
+	d.mu.Lock()
+	order, err := d.sort()
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	type trigger struct {
+		node   *dagNode
+		parent *ParentResult
+	}
+	var queue []trigger
+	for _, n := range order {
+		if len(n.parents) == 0 {
+			queue = append(queue, trigger{node: n})
+		}
+	}
+	var runs []DAGRun
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		run := DAGRun{Handler: t.node.handler, Params: t.node.info.ExecutorParams}
+		if t.parent != nil {
+			run.Parent = t.parent.Handler
+			if run.Params == "" {
+				run.Params = t.parent.Msg
+			}
+		}
+		run.Result = d.trigger(e, run.Params, t.node.handler, t.parent)
+		runs = append(runs, run)
+		if run.Result.Code != 200 {
+			continue
+		}
+		for _, c := range t.node.children {
+			queue = append(queue, trigger{node: c, parent: &ParentResult{Handler: t.node.handler, Msg: run.Result.Msg}})
+		}
+	}
+	return runs, nil
+}
+
+//本地触发一次任务,父任务结果按本次的LogID传给任务函数
+func (d *DAG) trigger(e Executor, params, handler string, parent *ParentResult) Result {
+	if parent == nil {
+		return e.TriggerLocal(handler, params)
+	}
+	logID := -atomic.AddInt64(&localSeq, 1)
+	d.mu.Lock()
+	d.parents[logID] = *parent
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.parents, logID)
+		d.mu.Unlock()
+	}()
+	return e.TriggerLocal(handler, params, TriggerLogID(logID))
+}
+
+//解析ChildJobId,多个子任务ID以逗号分隔
+func ParseChildJobIDs(s string) ([]int, error) {
+	var ids []int
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid child job id %q", v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+//格式化ChildJobId
+func FormatChildJobIDs(ids []int) string {
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, strconv.Itoa(id))
+	}
+	return strings.Join(list, ",")
+}
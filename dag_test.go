@@ -0,0 +1,138 @@
+package xxl_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+	"github.com/konglong87/xxl-job-executor-go/xxltest"
+	"gotest.tools/assert"
+)
+
+func echo(name string) xxl.TaskFunc {
+	return func(cxt context.Context, param *xxl.RunReq) string {
+		return name + "(" + param.ExecutorParams + ")"
+	}
+}
+
+func TestDAGValidate(t *testing.T) {
+	dag := xxl.NewDAG().
+		Job("a", echo("a"), xxl.AddJobInfo{}).
+		Job("b", echo("b"), xxl.AddJobInfo{}).
+		Job("c", echo("c"), xxl.AddJobInfo{}).
+		Edge("a", "b").
+		Edge("b", "c")
+	assert.NilError(t, dag.Validate())
+	dag.Edge("c", "a")
+	assert.Error(t, dag.Validate(), "xxl dag: cycle a -> b -> c -> a")
+
+	assert.Error(t, xxl.NewDAG().Job("a", nil, xxl.AddJobInfo{}).Edge("a", "a").Validate(), "xxl dag: cycle a -> a")
+	err := xxl.NewDAG().Job("a", nil, xxl.AddJobInfo{}).Job("a", nil, xxl.AddJobInfo{}).Edge("a", "x").Validate()
+	assert.ErrorContains(t, err, "duplicate job a")
+	assert.ErrorContains(t, err, "unknown job x")
+}
+
+func TestDAGRunLocal(t *testing.T) {
+	exec := xxl.NewExecutor(xxl.ServerAddr("http://127.0.0.1:1"))
+	exec.Init()
+	var (
+		mu      sync.Mutex
+		parents []string
+	)
+	dag := xxl.NewDAG().
+		Job("extract", echo("extract"), xxl.AddJobInfo{ExecutorParams: "day=1"}).
+		Job("left", echo("left"), xxl.AddJobInfo{}).
+		Job("right", echo("right"), xxl.AddJobInfo{ExecutorParams: "fixed"}).
+		Job("load", func(cxt context.Context, param *xxl.RunReq) string {
+			p, ok := xxl.TaskParent(cxt)
+			assert.Assert(t, ok)
+			mu.Lock()
+			parents = append(parents, p.Handler)
+			mu.Unlock()
+			return "load(" + param.ExecutorParams + ")"
+		}, xxl.AddJobInfo{}).
+		Job("broken", func(cxt context.Context, param *xxl.RunReq) string { panic("boom") }, xxl.AddJobInfo{}).
+		Job("never", echo("never"), xxl.AddJobInfo{}).
+		Edge("extract", "left", "right", "broken").
+		Edge("left", "load").
+		Edge("right", "load").
+		Edge("broken", "never")
+	dag.Register(exec)
+
+	runs, err := dag.RunLocal(exec)
+	assert.NilError(t, err)
+	got := make([]string, 0, len(runs))
+	for _, r := range runs {
+		got = append(got, r.Parent+" > "+r.Handler+" "+r.Params+" = "+xxl.Int64ToStr(r.Result.Code)+" "+r.Result.Msg)
+	}
+	assert.DeepEqual(t, []string{
+		" > extract day=1 = 200 extract(day=1)",
+		"extract > left extract(day=1) = 200 left(extract(day=1))",
+		"extract > right fixed = 200 right(fixed)",
+		"extract > broken extract(day=1) = 500 " + runs[3].Result.Msg,
+		"left > load left(extract(day=1)) = 200 load(left(extract(day=1)))",
+		"right > load right(fixed) = 200 load(right(fixed))",
+	}, got)
+	assert.Assert(t, strings.Contains(runs[3].Result.Msg, "boom"))
+	assert.DeepEqual(t, []string{"left", "right"}, parents)
+
+	//不经过RunLocal触发的子任务没有父任务结果,也不使用其他执行的结果作为参数
+	assert.Equal(t, int64(200), exec.TriggerLocal("extract", "day=2").Code)
+	assert.Equal(t, "left()", exec.TriggerLocal("left", "").Msg)
+
+	_, err = xxl.NewDAG().Job("a", nil, xxl.AddJobInfo{}).Edge("a", "a").RunLocal(exec)
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestDAGCreate(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	exec := xxl.NewExecutor(xxl.ServerAddr(admin.URL))
+	exec.Init()
+	dag := xxl.NewDAG().
+		Job("etl.extract", nil, xxl.AddJobInfo{JobGroupID: 2, ScheduleType: "CRON", ScheduleConf: "0 0 1 * * ?"}).
+		Job("etl.transform", nil, xxl.AddJobInfo{JobGroupID: 2}).
+		Job("etl.report", nil, xxl.AddJobInfo{JobGroupID: 2}).
+		Job("etl.load", nil, xxl.AddJobInfo{JobGroupID: 2}).
+		Edge("etl.extract", "etl.transform", "etl.report").
+		Edge("etl.transform", "etl.load")
+
+	ids, err := dag.Create(context.Background(), exec)
+	assert.NilError(t, err)
+	assert.Equal(t, 4, len(ids))
+	jobs := make(map[string]xxltest.Job)
+	for _, j := range admin.Jobs() {
+		jobs[j.Params["executorHandler"]] = j
+	}
+	assert.Equal(t, 4, len(jobs))
+	for handler, id := range ids {
+		assert.Equal(t, id, jobs[handler].ID)
+	}
+	children, err := xxl.ParseChildJobIDs(jobs["etl.extract"].Params["childJobId"])
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []int{ids["etl.transform"], ids["etl.report"]}, children)
+	assert.Equal(t, xxl.Int64ToStr(int64(ids["etl.load"])), jobs["etl.transform"].Params["childJobId"])
+	assert.Equal(t, "", jobs["etl.load"].Params["childJobId"])
+	assert.Equal(t, "CRON", jobs["etl.extract"].Params["scheduleType"])
+	assert.Equal(t, "NONE", jobs["etl.load"].Params["scheduleType"])
+	assert.Equal(t, "BEAN", jobs["etl.load"].Params["glueType"])
+
+	//创建失败时返回已创建的任务
+	admin.Inject("/jobinfo/add", xxltest.Fault{Body: `{"code":500,"msg":"db down"}`})
+	ids, err = dag.Create(context.Background(), exec)
+	assert.ErrorContains(t, err, "db down")
+	assert.Equal(t, 0, len(ids))
+}
+
+func TestChildJobIDs(t *testing.T) {
+	ids, err := xxl.ParseChildJobIDs(" 3, 5,,7 ")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []int{3, 5, 7}, ids)
+	assert.Equal(t, "3,5,7", xxl.FormatChildJobIDs(ids))
+	ids, err = xxl.ParseChildJobIDs("")
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(ids))
+	_, err = xxl.ParseChildJobIDs("1,x")
+	assert.ErrorContains(t, err, `"x"`)
+}
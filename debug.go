@@ -0,0 +1,46 @@
+package xxl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/pprof"
+)
+
+/**
+调试模式,默认关闭,通过xxl.Debug(true)开启,开启后在执行器路由上增加:
+	/debug/echo   解析请求体为RunReq并原样返回,用于检查调度中心发送的参数
+	/debug/panic  handler内panic,用于验证panic恢复
+	/debug/pprof/ 性能分析
+不要在生产环境开启
+*/
+
+//注册调试路由
+func (e *executor) debugRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/echo", e.debugEcho)
+	mux.HandleFunc("/debug/panic", e.debugPanic)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+//返回解析后的调度参数
+func (e *executor) debugEcho(writer http.ResponseWriter, request *http.Request) {
+	req, _ := ioutil.ReadAll(request.Body)
+	param := &RunReq{}
+	if err := json.Unmarshal(req, &param); err != nil {
+		writeRes(writer, http.StatusBadRequest, e.opts.lang.Msgf(MsgParamsErr, err.Error()))
+		return
+	}
+	e.logInfo(MsgTaskParams, runFields(param, "params", param.ExecutorParams)...)
+	str, _ := json.Marshal(param)
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_, _ = writer.Write(str)
+}
+
+//测试panic恢复
+func (e *executor) debugPanic(writer http.ResponseWriter, request *http.Request) {
+	panic("debug panic")
+}
@@ -61,6 +61,11 @@ type killReq struct {
 	JobID int64 `json:"jobId"` // 任务ID
 }
 
+//忙碌检测请求
+type idleBeatReq struct {
+	JobID int64 `json:"jobId"` // 任务ID
+}
+
 //日志请求
 type LogReq struct {
 	LogDateTim  int64 `json:"logDateTim"`  // 本次调度日志时间
@@ -30,6 +30,7 @@ func main() {
 	exec.RegTask("task.test", task.Test)
 	exec.RegTask("task.test2", task.Test2)
 	exec.RegTask("task.panic", task.Panic)
+	xxl.RegTyped(exec, "task.typed", task.Typed)
 	log.Fatal(exec.Run())
 }
 
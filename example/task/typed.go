@@ -0,0 +1,18 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	xxl "github.com/konglong87/xxl-job-executor-go"
+)
+
+//任务参数,调度中心可填 {"id":99} 或 id=99&name=test
+type TypedParams struct {
+	Id   int64  `json:"id" validate:"required"`
+	Name string `json:"name" default:"all"`
+}
+
+func Typed(cxt context.Context, params TypedParams, param *xxl.RunReq) xxl.Result {
+	fmt.Println("typed task id:", params.Id, " name:", params.Name)
+	return xxl.Result{Msg: "typed done"}
+}
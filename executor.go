@@ -2,18 +2,22 @@ package xxl
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
-	"strings"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //执行器
@@ -22,14 +26,28 @@ type Executor interface {
 	Init(...Option)
 	//日志查询
 	LogHandler(handler LogHandler)
-	//注册任务
-	RegTask(pattern string, task TaskFunc)
+	//注册任务,可设置默认超时等选项
+	RegTask(pattern string, task TaskFunc, opts ...TaskOption)
 	//运行任务
 	RunTask(writer http.ResponseWriter, request *http.Request)
 	//杀死任务
 	KillTask(writer http.ResponseWriter, request *http.Request)
 	//任务日志
 	TaskLog(writer http.ResponseWriter, request *http.Request)
+	//本地触发任务,同步返回结果,不回调调度中心
+	TriggerLocal(handler, params string, opts ...TriggerOption) Result
+	//已注册的任务
+	Handlers() []HandlerInfo
+	//查找调度中心JobHandler对应的任务,与调度相同按pattern匹配
+	LookupHandler(name string) (HandlerInfo, bool)
+	//取消注册任务
+	Unregister(name string) bool
+	//最近执行记录
+	History(q HistoryQuery) []RunRecord
+	//并发使用情况
+	Concurrency() ConcurrencyStats
+	//执行器全部路由,可挂载到已有服务上
+	Handler() http.Handler
 	//运行服务
 	Run() error
 	//动态增加一个任务
@@ -38,6 +56,13 @@ type Executor interface {
 	AddJobByPostForm(taskInfo AddJobInfo) (respBody []byte, err error)
 
 	StartJob(jobID string) (respBody []byte, err error)
+	//停止一个任务,以表单提交id(与java调度中心/jobinfo/stop一致,之前为json),返回响应体与错误
+	StopJob(jobID int) (respBody []byte, err error)
+	//可取消的调度中心接口,失败时返回*AdminError
+	AddJobContext(cxt context.Context, taskInfo AddJobInfo) (respBody []byte, err error)
+	AddJobByPostFormContext(cxt context.Context, taskInfo AddJobInfo) (respBody []byte, err error)
+	StartJobContext(cxt context.Context, jobID string) (respBody []byte, err error)
+	StopJobContext(cxt context.Context, jobID int) (respBody []byte, err error)
 }
 
 //创建执行器
@@ -50,16 +75,34 @@ func newExecutor(opts ...Option) *executor {
 	executor := &executor{
 		opts: options,
 	}
+	executor.cxt, executor.cancel = context.WithCancel(context.Background())
 	return executor
 }
 
 type executor struct {
-	opts    Options
-	address string
-	regList *taskList //注册任务列表
-	runList *taskList //正在执行任务列表
-	mu      sync.RWMutex
-	log     Logger
+	opts      Options
+	address   string    //监听地址
+	advertise string    //注册到调度中心的地址,为空时使用监听地址
+	regList   *taskList //注册任务列表
+	runList   *taskList //正在执行任务列表
+	mu        sync.RWMutex
+	log       StructuredLogger
+	metrics   *metrics //指标,未开启时为nil
+	limiter   *limiter //并发限制
+	history   *history //最近执行记录
+	tracer    trace.Tracer
+
+	registered int32     //是否已注册成功,1为是
+	heartbeat  heartbeat //最近一次注册心跳
+	shutdown   int32     //是否正在停止,1为是
+
+	cxt    context.Context    //执行器生命周期,注册心跳与日志清理使用,Run返回时取消;任务与回调不受影响
+	cancel context.CancelFunc //取消cxt
+
+	admins    []string     //调度中心地址
+	client    *http.Client //请求调度中心
+	tlsConfig *tls.Config  //执行器服务端TLS,为nil时使用http
+	initErr   error        //初始化错误,Run时返回
 
 	logHandler LogHandler //日志查询handler
 }
@@ -75,45 +118,234 @@ func (e *executor) Init(opts ...Option) {
 	e.runList = &taskList{
 		data: make(map[string]*Task),
 	}
-	e.address = e.opts.ExecutorIp + ":" + e.opts.ExecutorPort
+	e.history = newHistory(e.opts.historySize)
+	e.limiter = newLimiter(e.opts.maxConcurrency, e.opts.handlerConcurrency, e.opts.limitPolicy, e.opts.queueSize)
+	if e.opts.registry != nil {
+		e.metrics, e.initErr = newMetrics(e.opts.registry, e.opts.gatherer, e.runList.Len, e.limiter)
+	}
+	e.tracer = newTracer(e.opts.tracerProvider)
+	if e.opts.alerter != nil {
+		alerts := newAlerts(e.opts.alerter, e.opts.alertPolicy, e.opts.RegistryKey, e.opts.lang, e.log)
+		e.opts.hooks = append(e.opts.hooks[:len(e.opts.hooks):len(e.opts.hooks)], alerts.hooks())
+	}
+	//设置了对外地址时,未设置ExecutorIp则监听全部网卡
+	if e.initErr == nil && e.opts.ExecutorIp == "" && e.opts.AdvertiseAddr == "" {
+		e.opts.ExecutorIp, e.initErr = e.opts.ipDiscovery.Discover()
+	}
+	e.address = net.JoinHostPort(e.opts.ExecutorIp, e.opts.ExecutorPort)
+	if e.initErr == nil && e.opts.AdvertiseAddr != "" {
+		e.advertise, e.initErr = parseAdvertiseAddr(e.opts.AdvertiseAddr)
+	}
+	e.admins = parseAdminAddrs(e.opts.ServerAddr)
+	//总是创建客户端,之前的步骤失败时Handler()仍可能被调用
+	client, err := e.newClient()
+	e.client = client
+	if e.initErr == nil {
+		e.initErr = err
+	}
+	if e.initErr == nil {
+		e.initErr = e.initTLS()
+	}
+	if e.initErr != nil {
+		e.logError(MsgInitFailed, "err", e.initErr)
+		return
+	}
 	go e.registry()
+	if e.opts.LogDir != "" && e.opts.LogRetentionDays >= minLogRetentionDays {
+		go e.cleanLogs()
+	}
 }
 
-//日志handler
-func (e *executor) LogHandler(handler LogHandler) {
-	e.logHandler = handler
+//初始化执行器服务端的TLS,调度中心客户端的TLS在newClient中设置;
+//TLS优先于自定义server的TLSConfig,设置TLSClientCA时在TLSConfig的副本上开启mTLS,没有执行器证书时返回错误
+func (e *executor) initTLS() (err error) {
+	switch {
+	case e.opts.tlsCertFile != "":
+		if e.tlsConfig, err = serverTLSConfig(e.opts.tlsCertFile, e.opts.tlsKeyFile, e.opts.tlsClientCAFile); err != nil {
+			return err
+		}
+	case e.opts.server != nil && e.opts.server.TLSConfig != nil:
+		e.tlsConfig = e.opts.server.TLSConfig
+		if e.opts.tlsClientCAFile != "" {
+			pool, err := loadCertPool(e.opts.tlsClientCAFile)
+			if err != nil {
+				return errors.New("load executor client CA: " + err.Error())
+			}
+			e.tlsConfig = e.tlsConfig.Clone()
+			e.tlsConfig.ClientCAs = pool
+			e.tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	case e.opts.tlsClientCAFile != "":
+		return errors.New("xxl tls: TLSClientCA requires TLS(cert, key) or an HTTPServer with TLSConfig")
+	}
+	return nil
 }
 
-func (e *executor) Run() (err error) {
+//注册到调度中心的执行器地址
+func (e *executor) registryValue() string {
+	if e.advertise != "" {
+		return e.advertise
+	}
+	addr := e.address
+	if e.opts.listener != nil {
+		addr = listenerAddr(e.opts.listener.Addr(), e.opts.ExecutorIp)
+	}
+	if e.tlsConfig != nil {
+		return "https://" + addr
+	}
+	return "http://" + addr
+}
+
+//自定义监听的地址,监听全部网卡时使用ExecutorIp
+func listenerAddr(addr net.Addr, ip string) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() || ip == "" {
+		return addr.String()
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(tcp.Port))
+}
+
+//复制调用方的http.Server配置,Run不修改传入的server
+func cloneServer(s *http.Server) *http.Server {
+	return &http.Server{
+		Addr:                         s.Addr,
+		Handler:                      s.Handler,
+		DisableGeneralOptionsHandler: s.DisableGeneralOptionsHandler,
+		TLSConfig:                    s.TLSConfig,
+		ReadTimeout:                  s.ReadTimeout,
+		ReadHeaderTimeout:            s.ReadHeaderTimeout,
+		WriteTimeout:                 s.WriteTimeout,
+		IdleTimeout:                  s.IdleTimeout,
+		MaxHeaderBytes:               s.MaxHeaderBytes,
+		TLSNextProto:                 s.TLSNextProto,
+		ConnState:                    s.ConnState,
+		ErrorLog:                     s.ErrorLog,
+		BaseContext:                  s.BaseContext,
+		ConnContext:                  s.ConnContext,
+	}
+}
+
+//执行器全部路由,挂载到带前缀的路径时需去掉前缀,如:
+//	mux.Handle("/xxl-job/", http.StripPrefix("/xxl-job", exec.Handler()))
+func (e *executor) Handler() http.Handler {
+	return e.handler()
+}
+
+//执行器路由
+func (e *executor) handler() http.Handler {
 	// 创建路由器
 	mux := http.NewServeMux()
 	// 设置路由规则
 	mux.HandleFunc("/run", e.runTask)
 	mux.HandleFunc("/kill", e.killTask)
 	mux.HandleFunc("/log", e.taskLog)
-	mux.HandleFunc("/ppp", e.TestPanic)
-	mux.HandleFunc("/pan", e.TestPanic2)
-	mux.HandleFunc("/", e.TestResp)
+	mux.HandleFunc("/beat", e.beat)
+	mux.HandleFunc("/idleBeat", e.idleBeat)
+	mux.HandleFunc("/healthz", e.healthz)
+	mux.HandleFunc("/readyz", e.readyz)
+	mux.Handle("/handlers", e.consoleAuth(http.HandlerFunc(e.handlers)))
+	mux.Handle("/history", e.consoleAuth(http.HandlerFunc(e.historyHandler)))
+	if e.metrics != nil {
+		mux.Handle(e.opts.metricsPath, e.metrics.handler())
+	}
+	if e.opts.debug {
+		e.debugRoutes(mux)
+	}
+	if e.opts.console {
+		mux.Handle(consolePath, e.consoleAuth(e.consoleRoutes()))
+	}
+	mux.HandleFunc("/", e.notFound)
+	return e.recoverHandler(mux)
+}
+
+//日志handler
+func (e *executor) LogHandler(handler LogHandler) {
+	e.logHandler = handler
+}
+
+func (e *executor) Run() (err error) {
+	defer e.cancel()
+	if e.initErr != nil {
+		return e.initErr
+	}
 	// 创建服务器
-	server := &http.Server{
-		Addr:         e.address,
-		WriteTimeout: time.Second * 3,
-		Handler:      mux,
+	var server *http.Server
+	if e.opts.server != nil {
+		server = cloneServer(e.opts.server)
+	} else {
+		server = &http.Server{
+			WriteTimeout: time.Second * 3,
+		}
+	}
+	if server.Addr == "" {
+		server.Addr = e.address
+	}
+	if server.Handler == nil {
+		server.Handler = e.handler()
+	}
+	if e.tlsConfig != nil {
+		server.TLSConfig = e.tlsConfig
 	}
 	// 监听端口并提供服务
-	e.log.Info("[xxl-job-go] Starting server at listening: " + e.address)
-	go server.ListenAndServe()
-	quit := make(chan os.Signal)
+	errCh := make(chan error, 1)
+	go func() {
+		switch {
+		case e.opts.listener != nil && server.TLSConfig != nil:
+			e.logInfo(MsgServerStarted, "addr", e.opts.listener.Addr().String(), "tls", true)
+			errCh <- server.ServeTLS(e.opts.listener, "", "")
+		case e.opts.listener != nil:
+			e.logInfo(MsgServerStarted, "addr", e.opts.listener.Addr().String(), "tls", false)
+			errCh <- server.Serve(e.opts.listener)
+		case server.TLSConfig != nil:
+			e.logInfo(MsgServerStarted, "addr", server.Addr, "tls", true)
+			errCh <- server.ListenAndServeTLS("", "")
+		default:
+			e.logInfo(MsgServerStarted, "addr", server.Addr, "tls", false)
+			errCh <- server.ListenAndServe()
+		}
+	}()
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGKILL, syscall.SIGQUIT, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	e.registryRemove()
-	return nil
+	defer signal.Stop(quit)
+	select {
+	case err = <-errCh:
+		atomic.StoreInt32(&e.shutdown, 1)
+		e.registryRemove(context.Background())
+		return err
+	case <-quit:
+	}
+	atomic.StoreInt32(&e.shutdown, 1)
+	cxt, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e.registryRemove(cxt)
+	return server.Shutdown(cxt)
+}
+
+//并发使用情况
+func (e *executor) Concurrency() ConcurrencyStats {
+	return e.limiter.stats()
 }
 
-//注册任务
-func (e *executor) RegTask(pattern string, task TaskFunc) {
-	var t = &Task{}
-	t.fn = task
+//注册任务,带类型参数的任务使用RegTyped
+func (e *executor) RegTask(pattern string, task TaskFunc, opts ...TaskOption) {
+	var t = &Task{lang: e.opts.lang}
+	t.fn = func(cxt context.Context, param *RunReq) Result {
+		return Result{Code: 200, Msg: task(cxt, param)}
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	if e.regList.Exists(pattern) {
+		switch e.opts.duplicatePolicy {
+		case DuplicatePanic:
+			panic("xxl: " + string(MsgTaskDuplicate) + " " + pattern)
+		case DuplicateKeep:
+			e.logWarn(MsgTaskDuplicate, "handler", pattern, "keep", "first")
+			return
+		default:
+			e.logWarn(MsgTaskDuplicate, "handler", pattern, "keep", "last")
+		}
+	}
 	e.regList.Set(pattern, t)
 	return
 }
@@ -126,50 +358,120 @@ func (e *executor) runTask(writer http.ResponseWriter, request *http.Request) {
 	param := &RunReq{}
 	err := json.Unmarshal(req, &param)
 	if err != nil {
-		_, _ = writer.Write(returnCall(param, 500, "params err"))
-		e.log.Error("参数解析错误:" + string(req))
+		e.metrics.reject(unknownHandler, rejectBadParams)
+		_, _ = writer.Write(returnCall(param, 500, e.opts.lang.Msg(MsgParamsErr)))
+		e.logError(MsgParamsErr, "body", string(req), "err", err)
 		return
 	}
-	e.log.Info("任务参数:%v", param)
-	if !e.regList.Exists(param.ExecutorHandler) {
-		_, _ = writer.Write(returnCall(param, 500, "Task not registered"))
-		e.log.Error("任务[" + Int64ToStr(param.JobID) + "]没有注册:" + param.ExecutorHandler)
+	//初始化失败时拒绝调度,避免任务结束后无法回调
+	if e.initErr != nil {
+		_, _ = writer.Write(returnCall(param, 500, e.opts.lang.Msgf(MsgInitFailed, e.initErr.Error())))
 		return
 	}
+	e.logInfo(MsgTaskParams, runFields(param, "params", param.ExecutorParams, "blockStrategy", param.ExecutorBlockStrategy, "timeout", param.ExecutorTimeout)...)
+	cxt, span := e.tracer.Start(extractTrace(context.Background(), request), "xxl.run "+param.ExecutorHandler,
+		trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(runAttributes(param)...))
+	if code, msg := e.dispatch(cxt, span, param, e.callback); code != http.StatusOK {
+		_, _ = writer.Write(returnCall(param, code, msg))
+		return
+	}
+	_, _ = writer.Write(returnGeneral())
+}
+
+//按阻塞策略、并发限制调度任务,被拒绝时返回非200的code与原因;任务结束后调用finish回报结果
+func (e *executor) dispatch(cxt context.Context, span trace.Span, param *RunReq, finish func(cxt context.Context, task *Task, code int64, msg string)) (int64, string) {
+	reg, vars := e.matchTask(param.ExecutorHandler)
+	if reg == nil {
+		e.metrics.trigger(unknownHandler)
+		e.metrics.reject(unknownHandler, rejectNotRegistered)
+		spanResult(span, 500, string(MsgTaskNotRegistered))
+		span.End()
+		e.logError(MsgTaskNotRegistered, runFields(param)...)
+		return 500, e.opts.lang.Msg(MsgTaskNotRegistered)
+	}
+
+	e.metrics.trigger(vars.pattern)
 
 	//阻塞策略处理
-	if e.runList.Exists(Int64ToStr(param.JobID)) {
-		if param.ExecutorBlockStrategy == coverEarly { //覆盖之前调度
-			oldTask := e.runList.Get(Int64ToStr(param.JobID))
-			if oldTask != nil {
-				oldTask.Cancel()
-				e.runList.Del(Int64ToStr(oldTask.Id))
-			}
-		} else { //单机串行,丢弃后续调度 都进行阻塞
-			_, _ = writer.Write(returnCall(param, 500, "There are tasks running"))
-			e.log.Error("任务[" + Int64ToStr(param.JobID) + "]已经在运行了:" + param.ExecutorHandler)
-			return
+	running := e.runList.Exists(Int64ToStr(param.JobID))
+	if running && param.ExecutorBlockStrategy != coverEarly { //单机串行,丢弃后续调度 都进行阻塞
+		e.metrics.reject(vars.pattern, rejectBlocked)
+		spanResult(span, 500, string(MsgTaskRunning))
+		span.End()
+		e.logWarn(MsgTaskRunning, runFields(param)...)
+		return 500, e.opts.lang.Msg(MsgTaskRunning)
+	}
+
+	if running { //覆盖之前调度,先终止再获取执行名额
+		oldTask := e.runList.Get(Int64ToStr(param.JobID))
+		if oldTask != nil {
+			oldTask.kill()
+			e.runList.Del(Int64ToStr(oldTask.Id))
 		}
 	}
 
-	cxt := context.Background()
-	task := e.regList.Get(param.ExecutorHandler)
-	if param.ExecutorTimeout > 0 {
-		task.Ext, task.Cancel = context.WithTimeout(cxt, time.Duration(param.ExecutorTimeout)*time.Second)
+	//并发限制,覆盖时等待被终止的任务释放名额
+	acquired, ok := e.limiter.admit(vars.pattern, running)
+	if !ok {
+		e.metrics.reject(vars.pattern, rejectBusy)
+		spanResult(span, 500, string(MsgExecutorBusy))
+		span.End()
+		e.logWarn(MsgExecutorBusy, runFields(param)...)
+		return 500, e.opts.lang.Msg(MsgExecutorBusy)
+	}
+
+	//每次调度使用独立的Task,避免并发调度同一handler时互相覆盖
+	task := &Task{fn: reg.fn, timeout: reg.timeout, maxTimeout: reg.maxTimeout}
+	taskCxt := context.WithValue(cxt, taskVarsKey{}, vars)
+	if timeout := task.timeoutFor(param); timeout > 0 {
+		task.Ext, task.Cancel = context.WithTimeout(taskCxt, timeout)
 	} else {
-		task.Ext, task.Cancel = context.WithCancel(cxt)
+		task.Ext, task.Cancel = context.WithCancel(taskCxt)
 	}
 	task.Id = param.JobID
 	task.Name = param.ExecutorHandler
+	task.pattern = vars.pattern
 	task.Param = param
 	task.log = e.log
+	task.lang = e.opts.lang
+	task.metrics = e.metrics
+	task.abandonedRuns = &e.limiter.abandoned
+	task.tracer = e.tracer
+	task.hooks = append(e.opts.hooks[:len(e.opts.hooks):len(e.opts.hooks)], reg.hooks...)
 
+	start := time.Now()
+	task.StartTime = start.UnixMilli()
 	e.runList.Set(Int64ToStr(task.Id), task)
-	go task.Run(func(code int64, msg string) {
-		e.callback(task, code, msg)
-	})
-	e.log.Info("任务[" + Int64ToStr(param.JobID) + "]开始执行:" + param.ExecutorHandler)
-	_, _ = writer.Write(returnGeneral())
+	callback := func(code int64, msg string) {
+		defer span.End()
+		end := time.Now()
+		task.EndTime = end.UnixMilli()
+		e.metrics.finish(task.pattern, code, end.Sub(start))
+		spanResult(span, code, msg)
+		if ev := task.resultEvent(code); ev != "" {
+			task.hook(cxt, ev, task.runInfo(end, code, msg), nil)
+		}
+		e.record(task, code, msg)
+		//coverEarly时runList中可能已经是新的调度
+		if e.runList.Get(Int64ToStr(task.Id)) == task {
+			e.runList.Del(Int64ToStr(task.Id))
+		}
+		finish(cxt, task, code, msg)
+	}
+	go func() {
+		if !acquired {
+			//排队中被终止或超时
+			if err := e.limiter.wait(task.Ext, task.pattern); err != nil {
+				callback(500, e.opts.lang.Msgf(MsgTaskCanceled, err.Error()))
+				return
+			}
+		}
+		defer e.limiter.release(task.pattern)
+		task.hook(cxt, hookStart, task.runInfo(time.Now(), 0, ""), nil)
+		task.Run(callback)
+	}()
+	e.logInfo(MsgTaskStarted, runFields(param)...)
+	return http.StatusOK, ""
 }
 
 //删除一个任务
@@ -179,41 +481,72 @@ func (e *executor) killTask(writer http.ResponseWriter, request *http.Request) {
 	req, _ := ioutil.ReadAll(request.Body)
 	param := &killReq{}
 	_ = json.Unmarshal(req, &param)
-	if !e.runList.Exists(Int64ToStr(param.JobID)) {
-		_, _ = writer.Write(returnKill(param, 500))
-		e.log.Error("任务[" + Int64ToStr(param.JobID) + "]没有运行")
+	if !e.kill(param.JobID) {
+		_, _ = writer.Write(returnKill(param, 500, e.opts.lang.Msg(MsgTaskNotRunning)))
+		e.logWarn(MsgTaskNotRunning, "jobId", param.JobID)
 		return
 	}
-	task := e.runList.Get(Int64ToStr(param.JobID))
-	task.Cancel()
-	e.runList.Del(Int64ToStr(param.JobID))
 	_, _ = writer.Write(returnGeneral())
 }
 
+//心跳检测,调度中心故障转移(FAILOVER)时调用
+func (e *executor) beat(writer http.ResponseWriter, request *http.Request) {
+	_, _ = writer.Write(returnGeneral())
+}
+
+//忙碌检测,调度中心忙碌转移(BUSYOVER)时调用;任务正在执行或全局并发已满时返回500,调度中心转到其他执行器
+func (e *executor) idleBeat(writer http.ResponseWriter, request *http.Request) {
+	req, _ := ioutil.ReadAll(request.Body)
+	param := &idleBeatReq{}
+	_ = json.Unmarshal(req, &param)
+	switch {
+	case e.runList.Exists(Int64ToStr(param.JobID)):
+		_, _ = writer.Write(returnIdleBeat(param, 500, e.opts.lang.Msg(MsgTaskRunning)))
+	case e.limiter.saturated():
+		_, _ = writer.Write(returnIdleBeat(param, 500, e.opts.lang.Msg(MsgExecutorBusy)))
+	default:
+		_, _ = writer.Write(returnGeneral())
+	}
+}
+
+//终止正在执行的任务,任务没有运行时返回false
+func (e *executor) kill(jobID int64) bool {
+	task := e.runList.Get(Int64ToStr(jobID))
+	if task == nil {
+		return false
+	}
+	task.kill()
+	e.metrics.kill(task.pattern)
+	e.runList.Del(Int64ToStr(jobID))
+	return true
+}
+
 //任务日志
 func (e *executor) taskLog(writer http.ResponseWriter, request *http.Request) {
-	var res *LogRes
 	data, err := ioutil.ReadAll(request.Body)
 	req := &LogReq{}
 	if err != nil {
-		e.log.Error("日志请求失败:" + err.Error())
+		e.logError(MsgLogReqFailed, "err", err)
 		reqErrLogHandler(writer, req, err)
 		return
 	}
 	err = json.Unmarshal(data, &req)
 	if err != nil {
-		e.log.Error("日志请求解析失败:" + err.Error())
+		e.logError(MsgLogReqFailed, "err", err)
 		reqErrLogHandler(writer, req, err)
 		return
 	}
-	e.log.Info("日志请求参数:%+v", req)
+	e.logDebug(MsgLogReq, "logId", req.LogID, "fromLineNum", req.FromLineNum)
+	str, _ := json.Marshal(e.readLog(req))
+	_, _ = writer.Write(str)
+}
+
+//查询任务日志
+func (e *executor) readLog(req *LogReq) *LogRes {
 	if e.logHandler != nil {
-		res = e.logHandler(req)
-	} else {
-		res = defaultLogHandler(req)
+		return e.logHandler(req)
 	}
-	str, _ := json.Marshal(res)
-	_, _ = writer.Write(str)
+	return defaultLogHandler(req, e.opts.lang)
 }
 
 //注册执行器到调度中心
@@ -224,91 +557,72 @@ func (e *executor) registry() {
 	req := &Registry{
 		RegistryGroup: "EXECUTOR",
 		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
+		RegistryValue: e.registryValue(),
 	}
 	if e.opts.AccessToken != "" {
 		req.AccessToken = e.opts.AccessToken
 	}
-	param, err := json.Marshal(req)
-	if err != nil {
-		log.Fatal("执行器注册信息解析失败:" + err.Error())
-	}
-	fmt.Println("[注册xxl-job参数]", string(param))
+	e.logInfo(MsgRegistryParams, "registryKey", req.RegistryKey, "registryValue", req.RegistryValue)
 	for {
-		<-t.C
+		select {
+		case <-t.C:
+		case <-e.cxt.Done():
+			return
+		}
 		t.Reset(time.Second * time.Duration(20)) //20秒心跳防止过期
-		func() {
-			result, err := e.post("/api/registry", string(param))
-			if err != nil {
-				e.log.Error("执行器注册失败1:" + err.Error())
-				return
-			}
-			defer result.Body.Close()
-			body, err := ioutil.ReadAll(result.Body)
-			if err != nil {
-				e.log.Error("执行器注册失败2:" + err.Error())
-				return
-			}
-			res := &res{}
-			_ = json.Unmarshal(body, &res)
-			if res.Code != 200 {
-				e.log.Error("执行器注册失败3:" + string(body))
-				return
-			}
-			e.log.Info("执行器注册成功:" + string(body))
-		}()
-
+		if atomic.LoadInt32(&e.shutdown) == 1 {
+			return
+		}
+		body, err := e.postJSON(e.cxt, "/api/registry", req)
+		if err != nil {
+			e.metrics.registryFailure()
+			e.heartbeat.fail(err.Error())
+			e.logError(MsgRegistryFailed, "err", err)
+			continue
+		}
+		atomic.StoreInt32(&e.registered, 1)
+		e.heartbeat.ok()
+		e.logDebug(MsgRegistryOK, "body", string(body))
 	}
 }
 
 //执行器注册摘除
-func (e *executor) registryRemove() {
-	t := time.NewTimer(time.Second * 0) //初始立即执行
-	defer t.Stop()
+func (e *executor) registryRemove(cxt context.Context) {
 	req := &Registry{
 		RegistryGroup: "EXECUTOR",
 		RegistryKey:   e.opts.RegistryKey,
-		RegistryValue: "http://" + e.address,
+		RegistryValue: e.registryValue(),
 	}
-	param, err := json.Marshal(req)
+	body, err := e.postJSON(cxt, "/api/registryRemove", req)
 	if err != nil {
-		e.log.Error("执行器摘除失败:" + err.Error())
-	}
-	res, err := e.post("/api/registryRemove", string(param))
-	if err != nil {
-		e.log.Error("执行器摘除失败:" + err.Error())
+		e.logError(MsgRegistryRemoveFail, "err", err)
+		return
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	e.log.Info("执行器摘除成功:" + string(body))
-	_ = res.Body.Close()
+	e.logInfo(MsgRegistryRemoveOK, "registryKey", req.RegistryKey, "body", string(body))
 }
 
-//回调任务列表
-func (e *executor) callback(task *Task, code int64, msg string) {
-	res, err := e.post("/api/callback", string(returnCall(task.Param, code, msg)))
-	if err != nil {
-		e.log.Error("callback err : ", err.Error())
-	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		e.log.Error("callback ReadAll err : ", err.Error())
-	}
-	e.runList.Del(Int64ToStr(task.Id))
-	e.log.Info("任务回调成功:" + string(body))
-}
+//回调超时
+var callbackTimeout = 10 * time.Second
 
-//post
-func (e *executor) post(action, body string) (resp *http.Response, err error) {
-	request, err := http.NewRequest("POST", e.opts.ServerAddr+action, strings.NewReader(body))
+//回调任务列表,不随任务取消(终止、超时),使用独立的超时,Run返回后仍会回调
+func (e *executor) callback(cxt context.Context, task *Task, code int64, msg string) {
+	cxt, cancel := context.WithTimeout(context.WithoutCancel(cxt), callbackTimeout)
+	defer cancel()
+	cxt, span := e.tracer.Start(cxt, "xxl.callback", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	var err error
+	defer func() {
+		task.hook(cxt, hookCallback, task.runInfo(time.UnixMilli(task.EndTime), code, msg), err)
+	}()
+	body, err := e.call(cxt, "/api/callback", "application/json;charset=UTF-8", returnCall(task.Param, code, msg))
 	if err != nil {
-		return nil, err
-	}
-	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	request.Header.Set("XXL-JOB-ACCESS-TOKEN", e.opts.AccessToken)
-	client := http.Client{
-		Timeout: e.opts.Timeout,
+		e.metrics.callbackFailure()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		e.logError(MsgCallbackFailed, runFields(task.Param, "err", err)...)
+		return
 	}
-	return client.Do(request)
+	e.logInfo(MsgCallbackOK, runFields(task.Param, "code", code, "body", string(body))...)
 }
 
 //runTask
@@ -325,53 +639,3 @@ func (e *executor) KillTask(writer http.ResponseWriter, request *http.Request) {
 func (e *executor) TaskLog(writer http.ResponseWriter, request *http.Request) {
 	e.taskLog(writer, request)
 }
-
-//taskLog
-func (e *executor) TestPanic(writer http.ResponseWriter, request *http.Request) {
-	fmt.Println("[TestPanic]进来了")
-	panic("....TestPanic....test what will happen.....")
-}
-
-func (e *executor) TestPanic2(writer http.ResponseWriter, request *http.Request) {
-	fmt.Println("[TestPanic2]进来了")
-	go func() {
-		panic("....TestPanic2....test what will happen.....")
-	}()
-}
-
-func (e *executor) TestResp(writer http.ResponseWriter, request *http.Request) {
-	req, _ := ioutil.ReadAll(request.Body)
-	fmt.Println("[TestResp] request body-->", string(req))
-	param := &RunReq{}
-	err := json.Unmarshal(req, &param)
-	if err != nil {
-		_, _ = writer.Write(returnCall(param, 500, "params err"))
-		e.log.Error("参数解析错误:" + string(req))
-		return
-	}
-	e.log.Info("[TestResp] 任务参数:%v", param)
-	fmt.Println("[TestResp]this is TestResp, uri=", request.RequestURI)
-	writer.Write([]byte("[TestResp] xxl ok "))
-}
-
-//postForm
-func (e *executor) postForm(action string, data map[string]interface{}) (resp *http.Response, err error) {
-	reqForm := make(url.Values)
-	for k, v := range data {
-		reqForm.Add(k, fmt.Sprint(v))
-	}
-	//return http.DefaultClient.Post(e.opts.ServerAddr+action, "application/json;charset=UTF-8", strings.NewReader(reqForm.Encode()))
-	//request, err := http.NewRequest("POST", e.opts.ServerAddr+action, strings.NewReader(reqForm.Encode()))
-	//if err != nil {
-	//	return nil, err
-	//}
-	////request.Header.Set("Content-Type", "application/json;charset=UTF-8")
-	//request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	//request.Header.Set("XXL-JOB-ACCESS-TOKEN", e.opts.AccessToken)
-	//request.Header.Set("HOST", "go-xxl")
-	//client := http.Client{
-	//	Timeout: e.opts.Timeout,
-	//}
-	//return client.Do(request)
-	return http.PostForm(e.opts.ServerAddr+action, reqForm)
-}
@@ -0,0 +1,137 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestRunWithListener(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	defer admin.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	server := &http.Server{ReadTimeout: time.Second}
+	e := newExecutor(ServerAddr(admin.URL), Listener(l), HTTPServer(server))
+	e.Init()
+	done := make(chan error, 1)
+	go func() { done <- e.Run() }()
+	defer func() {
+		//关闭监听后Run返回并停止接收信号
+		_ = l.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("Run did not return")
+		}
+	}()
+
+	var resp *http.Response
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if resp, err = http.Get("http://" + l.Addr().String() + "/healthz"); err == nil {
+			break
+		}
+	}
+	assert.NilError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	//注册监听的地址,不修改传入的server
+	assert.Equal(t, "http://"+l.Addr().String(), e.registryValue())
+	assert.Equal(t, "", server.Addr)
+	assert.Assert(t, server.Handler == nil)
+}
+
+func TestRunCancelsContext(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	e := newExecutor(ServerAddr(admin.URL), Listener(l))
+	e.Init()
+	b := &blockingTask{release: make(chan struct{})}
+	e.RegTask("task.block", b.fn)
+	done := make(chan error, 1)
+	go func() { done <- e.Run() }()
+	assert.NilError(t, e.cxt.Err())
+	assert.Equal(t, int64(200), trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.block"}).ExecuteResult.Code)
+	waitFor(t, func() bool { return atomic.LoadInt64(&b.running) == 1 })
+
+	_ = l.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	//Run返回后注册心跳的context已取消,正在执行的任务不受影响,结束后仍回调调度中心
+	assert.Equal(t, context.Canceled, e.cxt.Err())
+	task := e.runList.Get("1")
+	assert.Assert(t, task != nil)
+	assert.NilError(t, task.Ext.Err())
+	close(b.release)
+	select {
+	case c := <-callbacks:
+		assert.Equal(t, int64(1), c.LogID)
+		assert.Equal(t, int64(200), c.ExecuteResult.Code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not received")
+	}
+}
+
+func TestListenerAddr(t *testing.T) {
+	assert.Equal(t, "127.0.0.1:8080", listenerAddr(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}, "10.0.0.1"))
+	assert.Equal(t, "10.0.0.1:8080", listenerAddr(&net.TCPAddr{IP: net.IPv4zero, Port: 8080}, "10.0.0.1"))
+	assert.Equal(t, "[::]:8080", listenerAddr(&net.TCPAddr{IP: net.IPv6unspecified, Port: 8080}, ""))
+}
+
+func TestHandlerWithPrefix(t *testing.T) {
+	e := newExecutor()
+	e.Init()
+	mux := http.NewServeMux()
+	mux.Handle("/xxl-job/", http.StripPrefix("/xxl-job", e.Handler()))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/xxl-job/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+//测试用调度中心,收到的回调写入callbacks
+func newTestAdmin(t *testing.T) (*httptest.Server, chan *callElement) {
+	callbacks := make(chan *callElement, 100)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/callback" {
+			var c call
+			_ = json.NewDecoder(r.Body).Decode(&c)
+			for _, el := range c {
+				callbacks <- el
+			}
+		}
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	t.Cleanup(admin.Close)
+	return admin, callbacks
+}
+
+//触发任务,返回响应
+func trigger(e *executor, param *RunReq) *callElement {
+	body, _ := json.Marshal(param)
+	w := httptest.NewRecorder()
+	e.runTask(w, httptest.NewRequest("POST", "/run", bytes.NewReader(body)))
+	r := &res{}
+	_ = json.Unmarshal(w.Body.Bytes(), r)
+	if r.Code == 200 {
+		return &callElement{LogID: param.LogID, ExecuteResult: &ExecuteResult{Code: 200}}
+	}
+	var c call
+	_ = json.Unmarshal(w.Body.Bytes(), &c)
+	return c[0]
+}
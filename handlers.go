@@ -0,0 +1,99 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+//重复注册同名任务时的处理策略
+type DuplicatePolicy int
+
+const (
+	DuplicateReplace DuplicatePolicy = iota //替换已注册的任务(默认),记录警告日志
+	DuplicateKeep                           //保留先注册的任务,记录警告日志
+	DuplicatePanic                          //panic,与http.ServeMux重复注册一致
+)
+
+//已注册任务信息
+type HandlerInfo struct {
+	Name        string        `json:"name"`                  // 任务名称,即调度中心的JobHandler
+	Pattern     string        `json:"pattern,omitempty"`     // LookupHandler匹配到的注册pattern,Handlers中为空(Name即pattern)
+	Description string        `json:"description,omitempty"` // 任务描述
+	Owner       string        `json:"owner,omitempty"`       // 负责人
+	Timeout     time.Duration `json:"-"`                     // 默认超时,0为不限制
+	MaxTimeout  time.Duration `json:"-"`                     // 最大超时,0为不限制
+}
+
+//json中超时时间使用10m0s格式
+func (h HandlerInfo) MarshalJSON() ([]byte, error) {
+	type info HandlerInfo
+	v := struct {
+		info
+		Timeout    string `json:"timeout,omitempty"`
+		MaxTimeout string `json:"maxTimeout,omitempty"`
+	}{info: info(h)}
+	if h.Timeout > 0 {
+		v.Timeout = h.Timeout.String()
+	}
+	if h.MaxTimeout > 0 {
+		v.MaxTimeout = h.MaxTimeout.String()
+	}
+	return json.Marshal(v)
+}
+
+//任务列表响应
+type HandlersRes struct {
+	Code     int64         `json:"code"`     // 200 表示正常
+	Msg      string        `json:"msg"`      // 状态说明
+	Handlers []HandlerInfo `json:"handlers"` // 已注册的任务,按名称排序
+}
+
+func (t *Task) info(name string) HandlerInfo {
+	return HandlerInfo{
+		Name:        name,
+		Description: t.desc,
+		Owner:       t.owner,
+		Timeout:     t.timeout,
+		MaxTimeout:  t.maxTimeout,
+	}
+}
+
+//已注册的任务,按名称排序
+func (e *executor) Handlers() []HandlerInfo {
+	names := e.regList.Keys()
+	list := make([]HandlerInfo, 0, len(names))
+	for _, name := range names {
+		if t := e.regList.Get(name); t != nil {
+			list = append(list, t.info(name))
+		}
+	}
+	return list
+}
+
+//查找调度中心JobHandler对应的任务,与调度时相同按pattern匹配(精确、分段{var}、前缀*、兜底*)
+func (e *executor) LookupHandler(name string) (HandlerInfo, bool) {
+	t, vars := e.matchTask(name)
+	if t == nil {
+		return HandlerInfo{}, false
+	}
+	info := t.info(name)
+	info.Pattern = vars.pattern
+	return info, true
+}
+
+//取消注册,正在执行的任务不受影响
+func (e *executor) Unregister(name string) bool {
+	if !e.regList.Exists(name) {
+		return false
+	}
+	e.regList.Del(name)
+	return true
+}
+
+//任务列表接口,可用于检查调度中心任务的JobHandler是否都已注册;与控制台相同需认证,未设置ConsoleBasicAuth或AccessToken时拒绝访问
+func (e *executor) handlers(writer http.ResponseWriter, request *http.Request) {
+	str, _ := json.Marshal(&HandlersRes{Code: http.StatusOK, Msg: "ok", Handlers: e.Handlers()})
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_, _ = writer.Write(str)
+}
@@ -0,0 +1,89 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func noopTask(msg string) TaskFunc {
+	return func(cxt context.Context, param *RunReq) string { return msg }
+}
+
+func TestHandlersRegistry(t *testing.T) {
+	e := newExecutor(AccessToken("secret"))
+	e.Init()
+	e.RegTask("task.b", noopTask("b"), TaskDescription("sync orders"), TaskOwner("ops"), TaskTimeout(10*time.Minute))
+	e.RegTask("task.a", noopTask("a"))
+
+	assert.DeepEqual(t, []HandlerInfo{
+		{Name: "task.a"},
+		{Name: "task.b", Description: "sync orders", Owner: "ops", Timeout: 10 * time.Minute},
+	}, e.Handlers())
+
+	info, ok := e.LookupHandler("task.b")
+	assert.Assert(t, ok)
+	assert.Equal(t, "ops", info.Owner)
+	assert.Equal(t, "task.b", info.Pattern)
+	_, ok = e.LookupHandler("task.c")
+	assert.Assert(t, !ok)
+
+	w := httptest.NewRecorder()
+	e.handler().ServeHTTP(w, httptest.NewRequest("GET", "/handlers", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/handlers", nil)
+	req.Header.Set("XXL-JOB-ACCESS-TOKEN", "secret")
+	e.handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"code":200,"msg":"ok","handlers":[{"name":"task.a"},{"name":"task.b","description":"sync orders","owner":"ops","timeout":"10m0s"}]}`, w.Body.String())
+
+	assert.Assert(t, e.Unregister("task.a"))
+	assert.Assert(t, !e.Unregister("task.a"))
+	assert.Equal(t, 1, len(e.Handlers()))
+	r := trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.a"})
+	assert.Equal(t, int64(500), r.ExecuteResult.Code)
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	run := func(e *executor) string {
+		trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.dup"})
+		return (<-callbacks).ExecuteResult.Msg.(string)
+	}
+
+	e := newExecutor(ServerAddr(admin.URL))
+	e.Init()
+	e.RegTask("task.dup", noopTask("first"))
+	e.RegTask("task.dup", noopTask("last"))
+	assert.Equal(t, "last", run(e))
+
+	e = newExecutor(ServerAddr(admin.URL), OnDuplicate(DuplicateKeep))
+	e.Init()
+	e.RegTask("task.dup", noopTask("first"))
+	e.RegTask("task.dup", noopTask("last"))
+	assert.Equal(t, "first", run(e))
+
+	e = newExecutor(OnDuplicate(DuplicatePanic))
+	e.Init()
+	e.RegTask("task.dup", noopTask("first"))
+	defer func() {
+		err := recover()
+		assert.Assert(t, strings.Contains(err.(string), "task.dup"))
+	}()
+	e.RegTask("task.dup", noopTask("last"))
+	t.Fatal("duplicate registration did not panic")
+}
+
+func TestHandlerInfoJSON(t *testing.T) {
+	b, err := json.Marshal(HandlerInfo{Name: "a", MaxTimeout: time.Hour})
+	assert.NilError(t, err)
+	assert.Equal(t, `{"name":"a","maxTimeout":"1h0m0s"}`, string(b))
+}
@@ -0,0 +1,83 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//健康检查响应
+type HealthRes struct {
+	Code        int64            `json:"code"`        // 200 表示正常、其他失败
+	Msg         string           `json:"msg"`         // 状态说明
+	Registered  bool             `json:"registered"`  // 是否已成功注册到调度中心
+	Shutdown    bool             `json:"shutdown"`    // 是否正在停止
+	Running     int              `json:"running"`     // 正在执行的任务数
+	Handlers    []string         `json:"handlers"`    // 已注册的任务
+	Concurrency ConcurrencyStats `json:"concurrency"` // 并发使用情况
+}
+
+//执行器状态
+func (e *executor) health() *HealthRes {
+	h := &HealthRes{
+		Code:        http.StatusOK,
+		Msg:         "ok",
+		Registered:  atomic.LoadInt32(&e.registered) == 1,
+		Shutdown:    atomic.LoadInt32(&e.shutdown) == 1,
+		Running:     e.runList.Len(),
+		Handlers:    e.regList.Keys(),
+		Concurrency: e.limiter.stats(),
+	}
+	return h
+}
+
+//存活检查,进程能响应即正常
+func (e *executor) healthz(writer http.ResponseWriter, request *http.Request) {
+	writeHealth(writer, e.health())
+}
+
+//就绪检查,注册成功且未停止时正常
+func (e *executor) readyz(writer http.ResponseWriter, request *http.Request) {
+	h := e.health()
+	if !h.Registered {
+		h.Code, h.Msg = http.StatusServiceUnavailable, e.opts.lang.Msg(MsgNotRegistered)
+	} else if h.Shutdown {
+		h.Code, h.Msg = http.StatusServiceUnavailable, e.opts.lang.Msg(MsgShuttingDown)
+	}
+	writeHealth(writer, h)
+}
+
+func writeHealth(writer http.ResponseWriter, h *HealthRes) {
+	str, _ := json.Marshal(h)
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	writer.WriteHeader(int(h.Code))
+	_, _ = writer.Write(str)
+}
+
+//注册心跳状态
+type heartbeat struct {
+	mu     sync.Mutex
+	at     time.Time //最近一次成功时间
+	failAt time.Time //最近一次失败时间
+	err    string    //最近一次失败原因,成功后清空
+}
+
+func (h *heartbeat) ok() {
+	h.mu.Lock()
+	h.at, h.err = time.Now(), ""
+	h.mu.Unlock()
+}
+
+func (h *heartbeat) fail(err string) {
+	h.mu.Lock()
+	h.failAt, h.err = time.Now(), err
+	h.mu.Unlock()
+}
+
+func (h *heartbeat) get() (at, failAt time.Time, err string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.at, h.failAt, h.err
+}
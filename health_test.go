@@ -0,0 +1,61 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestHealth(t *testing.T) {
+	var registryCode int32 = 500
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(res{Code: int64(atomic.LoadInt32(&registryCode))})
+	}))
+	defer admin.Close()
+
+	e := newExecutor(ServerAddr(admin.URL))
+	e.Init()
+	e.RegTask("task.b", func(cxt context.Context, param *RunReq) string { return "" })
+	e.RegTask("task.a", func(cxt context.Context, param *RunReq) string { return "" })
+
+	get := func(h http.HandlerFunc) (int, *HealthRes) {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/", nil))
+		res := &HealthRes{}
+		assert.NilError(t, json.Unmarshal(w.Body.Bytes(), res))
+		return w.Code, res
+	}
+
+	code, h := get(e.healthz)
+	assert.Equal(t, http.StatusOK, code)
+	assert.DeepEqual(t, []string{"task.a", "task.b"}, h.Handlers)
+
+	code, h = get(e.readyz)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, false, h.Registered)
+
+	//注册成功后就绪
+	atomic.StoreInt32(&registryCode, 200)
+	go e.registry()
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&e.registered) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	code, h = get(e.readyz)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, h.Registered)
+	assert.Equal(t, 0, h.Running)
+
+	//停止后不再就绪
+	atomic.StoreInt32(&e.shutdown, 1)
+	code, h = get(e.readyz)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, true, h.Shutdown)
+	code, _ = get(e.healthz)
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,152 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//默认保留的执行记录数
+var DefaultHistorySize = 200
+
+//执行记录
+type RunRecord struct {
+	JobID     int64     `json:"jobId"`     // 任务ID
+	LogID     int64     `json:"logId"`     // 本次调度日志ID
+	Handler   string    `json:"handler"`   // 任务名称
+	Params    string    `json:"params"`    // 任务参数
+	StartTime time.Time `json:"startTime"` // 收到调度的时间
+	EndTime   time.Time `json:"endTime"`   // 执行结束时间
+	Code      int64     `json:"code"`      // 执行结果,200为成功
+	Msg       string    `json:"msg"`       // 执行备注
+	Retries   int       `json:"retries"`   // 同一JobID此前连续失败的次数,即调度中心的失败重试
+	Killed    bool      `json:"killed"`    // 是否被终止或覆盖
+}
+
+//执行记录查询条件,零值字段不过滤
+type HistoryQuery struct {
+	JobID   int64  // 任务ID
+	LogID   int64  // 调度日志ID
+	Handler string // 任务名称
+	Failed  bool   // 只返回失败记录
+	Limit   int    // 最多返回条数,0为全部
+}
+
+//执行记录持久化,记录在执行结束后写入,写入失败只记录日志
+type HistoryBackend interface {
+	Save(r RunRecord) error
+}
+
+//执行记录响应
+type HistoryRes struct {
+	Code    int64       `json:"code"`    // 200 表示正常
+	Msg     string      `json:"msg"`     // 状态说明
+	History []RunRecord `json:"history"` // 执行记录,最新的在前
+}
+
+//最近执行记录,环形缓冲
+type history struct {
+	mu      sync.RWMutex
+	records []RunRecord
+	next    int //下一个写入位置
+	full    bool
+}
+
+func newHistory(size int) *history {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &history{records: make([]RunRecord, size)}
+}
+
+//写入记录,计算Retries
+func (h *history) add(r RunRecord) RunRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.each(func(prev *RunRecord) bool {
+		if prev.JobID != r.JobID {
+			return true
+		}
+		if prev.Code != 200 {
+			r.Retries = prev.Retries + 1
+		}
+		return false
+	})
+	h.records[h.next] = r
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+	return r
+}
+
+//从新到旧遍历,fn返回false时停止
+func (h *history) each(fn func(r *RunRecord) bool) {
+	n := h.next
+	if h.full {
+		n = len(h.records)
+	}
+	for i := 1; i <= n; i++ {
+		if !fn(&h.records[(h.next-i+len(h.records))%len(h.records)]) {
+			return
+		}
+	}
+}
+
+//查询,最新的在前
+func (h *history) query(q HistoryQuery) []RunRecord {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	list := make([]RunRecord, 0)
+	h.each(func(r *RunRecord) bool {
+		if (q.JobID != 0 && r.JobID != q.JobID) ||
+			(q.LogID != 0 && r.LogID != q.LogID) ||
+			(q.Handler != "" && r.Handler != q.Handler) ||
+			(q.Failed && r.Code == 200) {
+			return true
+		}
+		list = append(list, *r)
+		return q.Limit <= 0 || len(list) < q.Limit
+	})
+	return list
+}
+
+//记录一次执行
+func (e *executor) record(task *Task, code int64, msg string) {
+	r := e.history.add(RunRecord{
+		JobID:     task.Param.JobID,
+		LogID:     task.Param.LogID,
+		Handler:   task.Name,
+		Params:    task.Param.ExecutorParams,
+		StartTime: time.UnixMilli(task.StartTime),
+		EndTime:   time.UnixMilli(task.EndTime),
+		Code:      code,
+		Msg:       msg,
+		Killed:    task.Killed(),
+	})
+	if e.opts.historyBackend != nil {
+		if err := e.opts.historyBackend.Save(r); err != nil {
+			e.logError(MsgHistorySaveFailed, runFields(task.Param, "err", err)...)
+		}
+	}
+}
+
+//最近执行记录,最新的在前
+func (e *executor) History(q HistoryQuery) []RunRecord {
+	return e.history.query(q)
+}
+
+//执行记录接口,参数jobId、logId、handler、failed、limit;与控制台相同需认证,未设置ConsoleBasicAuth或AccessToken时拒绝访问
+func (e *executor) historyHandler(writer http.ResponseWriter, request *http.Request) {
+	v := request.URL.Query()
+	q := HistoryQuery{Handler: v.Get("handler")}
+	q.JobID, _ = strconv.ParseInt(v.Get("jobId"), 10, 64)
+	q.LogID, _ = strconv.ParseInt(v.Get("logId"), 10, 64)
+	q.Failed, _ = strconv.ParseBool(v.Get("failed"))
+	q.Limit, _ = strconv.Atoi(v.Get("limit"))
+	str, _ := json.Marshal(&HistoryRes{Code: http.StatusOK, Msg: "ok", History: e.History(q)})
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_, _ = writer.Write(str)
+}
@@ -0,0 +1,100 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHistoryRing(t *testing.T) {
+	h := newHistory(3)
+	assert.Equal(t, 0, len(h.query(HistoryQuery{})))
+	for i := int64(1); i <= 5; i++ {
+		code := int64(500)
+		if i == 5 {
+			code = 200
+		}
+		h.add(RunRecord{JobID: 1, LogID: i, Handler: "task.a", Code: code})
+	}
+	list := h.query(HistoryQuery{})
+	assert.Equal(t, 3, len(list))
+	assert.Equal(t, int64(5), list[0].LogID)
+	assert.Equal(t, int64(3), list[2].LogID)
+	assert.Equal(t, 4, list[0].Retries)
+	assert.Equal(t, 3, list[1].Retries)
+
+	h.add(RunRecord{JobID: 2, LogID: 6, Handler: "task.b", Code: 500})
+	assert.Equal(t, 0, h.query(HistoryQuery{JobID: 2})[0].Retries)
+	assert.Equal(t, 1, len(h.query(HistoryQuery{Handler: "task.b"})))
+	assert.Equal(t, 2, len(h.query(HistoryQuery{Failed: true})))
+	assert.Equal(t, int64(6), h.query(HistoryQuery{Limit: 1})[0].LogID)
+	assert.Equal(t, int64(4), h.query(HistoryQuery{LogID: 4})[0].LogID)
+	h.add(RunRecord{JobID: 1, LogID: 7, Code: 500})
+	assert.Equal(t, 0, h.query(HistoryQuery{LogID: 7})[0].Retries)
+}
+
+//记录保存的执行记录
+type memoryBackend struct {
+	mu      sync.Mutex
+	records []RunRecord
+}
+
+func (m *memoryBackend) Save(r RunRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, r)
+	if r.Code != 200 {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func TestExecutorHistory(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	backend := &memoryBackend{}
+	e := newExecutor(ServerAddr(admin.URL), HistoryStore(backend), AccessToken("secret"))
+	e.Init()
+	e.RegTask("task.ok", noopTask("done"))
+	e.RegTask("task.panic", func(cxt context.Context, param *RunReq) string { panic("boom") })
+
+	trigger(e, &RunReq{JobID: 1, LogID: 10, ExecutorHandler: "task.ok", ExecutorParams: "id=1"})
+	<-callbacks
+	trigger(e, &RunReq{JobID: 2, LogID: 11, ExecutorHandler: "task.panic"})
+	<-callbacks
+
+	list := e.History(HistoryQuery{})
+	assert.Equal(t, 2, len(list))
+	r := list[1]
+	assert.Equal(t, int64(10), r.LogID)
+	assert.Equal(t, "task.ok", r.Handler)
+	assert.Equal(t, "id=1", r.Params)
+	assert.Equal(t, int64(200), r.Code)
+	assert.Equal(t, "done", r.Msg)
+	assert.Assert(t, !r.StartTime.IsZero())
+	assert.Assert(t, !r.EndTime.Before(r.StartTime))
+	assert.Equal(t, int64(500), list[0].Code)
+
+	backend.mu.Lock()
+	assert.Equal(t, 2, len(backend.records))
+	backend.mu.Unlock()
+
+	w := httptest.NewRecorder()
+	e.handler().ServeHTTP(w, httptest.NewRequest("GET", "/history?handler=task.ok&limit=5", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/history?handler=task.ok&limit=5", nil)
+	req.Header.Set("XXL-JOB-ACCESS-TOKEN", "secret")
+	e.handler().ServeHTTP(w, req)
+	res := &HistoryRes{}
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), res))
+	assert.Equal(t, int64(200), res.Code)
+	assert.Equal(t, 1, len(res.History))
+	assert.Equal(t, int64(10), res.History[0].LogID)
+}
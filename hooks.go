@@ -0,0 +1,128 @@
+package xxl
+
+import (
+	"context"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+//任务执行信息,传给钩子
+type RunInfo struct {
+	Param    *RunReq       // 调度参数
+	Handler  string        // 任务名称
+	Pattern  string        // 匹配到的注册pattern,并发限制、指标与告警按此区分
+	Start    time.Time     // 收到调度的时间
+	Duration time.Duration // 执行耗时,含排队时间
+	Code     int64         // 执行结果,200为成功,OnStart时为0
+	Msg      string        // 执行备注
+	Panic    bool          // 任务函数是否panic
+}
+
+//任务生命周期钩子,未设置的钩子不调用
+//钩子在任务goroutine中同步执行,耗时操作请自行异步;钩子panic会被恢复并记录日志,不影响回调
+type Hooks struct {
+	OnStart    func(cxt context.Context, info *RunInfo)            // 任务函数执行前
+	OnSuccess  func(cxt context.Context, info *RunInfo)            // 执行成功,回调前
+	OnFailure  func(cxt context.Context, info *RunInfo)            // 执行失败、panic或排队时被取消,回调前
+	OnKill     func(cxt context.Context, info *RunInfo)            // 被调度中心终止或被覆盖(coverEarly),任务函数返回后、回调前调用,不再调用OnSuccess/OnFailure
+	OnTimeout  func(cxt context.Context, info *RunInfo)            // 执行超时,回调前
+	OnCallback func(cxt context.Context, info *RunInfo, err error) // 回调调度中心后,err为nil时回调成功
+}
+
+//钩子事件
+type hookEvent string
+
+const (
+	hookStart    hookEvent = "OnStart"
+	hookSuccess  hookEvent = "OnSuccess"
+	hookFailure  hookEvent = "OnFailure"
+	hookKill     hookEvent = "OnKill"
+	hookTimeout  hookEvent = "OnTimeout"
+	hookCallback hookEvent = "OnCallback"
+)
+
+//本次执行信息
+func (t *Task) runInfo(end time.Time, code int64, msg string) *RunInfo {
+	start := time.UnixMilli(t.StartTime)
+	return &RunInfo{
+		Param:    t.Param,
+		Handler:  t.Name,
+		Pattern:  t.pattern,
+		Start:    start,
+		Duration: end.Sub(start),
+		Code:     code,
+		Msg:      msg,
+		Panic:    t.Panicked(),
+	}
+}
+
+//执行结果对应的钩子事件
+func (t *Task) resultEvent(code int64) hookEvent {
+	switch {
+	case t.Killed():
+		return hookKill
+	case t.Abandoned():
+		return hookTimeout
+	case code == 200:
+		return hookSuccess
+	}
+	return hookFailure
+}
+
+//是否被终止或覆盖
+func (t *Task) Killed() bool {
+	return atomic.LoadInt32(&t.killed) == 1
+}
+
+//标记为被终止并取消,OnKill在任务goroutine中调用,不阻塞调度与终止
+func (t *Task) kill() {
+	atomic.StoreInt32(&t.killed, 1)
+	t.Cancel()
+}
+
+//依次调用执行器与任务的钩子
+func (t *Task) hook(cxt context.Context, ev hookEvent, info *RunInfo, err error) {
+	for _, h := range t.hooks {
+		var fn func()
+		switch ev {
+		case hookStart:
+			if h.OnStart != nil {
+				fn = func() { h.OnStart(cxt, info) }
+			}
+		case hookSuccess:
+			if h.OnSuccess != nil {
+				fn = func() { h.OnSuccess(cxt, info) }
+			}
+		case hookFailure:
+			if h.OnFailure != nil {
+				fn = func() { h.OnFailure(cxt, info) }
+			}
+		case hookKill:
+			if h.OnKill != nil {
+				fn = func() { h.OnKill(cxt, info) }
+			}
+		case hookTimeout:
+			if h.OnTimeout != nil {
+				fn = func() { h.OnTimeout(cxt, info) }
+			}
+		case hookCallback:
+			if h.OnCallback != nil {
+				fn = func() { h.OnCallback(cxt, info, err) }
+			}
+		}
+		if fn != nil {
+			t.safeHook(ev, fn)
+		}
+	}
+}
+
+//钩子panic时记录日志
+func (t *Task) safeHook(ev hookEvent, fn func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			t.log.Error(t.lang.Text(MsgHookPanic), runFields(t.Param, "msgCode", MsgHookPanic, "hook", string(ev), "err", err, "stack", string(debug.Stack()))...)
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,163 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+//记录钩子调用
+type hookRecorder struct {
+	mu     sync.Mutex
+	events []string
+	done   chan error
+}
+
+func newHookRecorder() *hookRecorder {
+	return &hookRecorder{done: make(chan error, 10)}
+}
+
+func (r *hookRecorder) add(ev string) {
+	r.mu.Lock()
+	r.events = append(r.events, ev)
+	r.mu.Unlock()
+}
+
+func (r *hookRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *hookRecorder) hooks(prefix string) Hooks {
+	return Hooks{
+		OnStart:   func(cxt context.Context, info *RunInfo) { r.add(prefix + "start") },
+		OnSuccess: func(cxt context.Context, info *RunInfo) { r.add(prefix + "success:" + info.Msg) },
+		OnFailure: func(cxt context.Context, info *RunInfo) { r.add(prefix + "failure:" + Int64ToStr(info.Code)) },
+		OnKill:    func(cxt context.Context, info *RunInfo) { r.add(prefix + "kill") },
+		OnTimeout: func(cxt context.Context, info *RunInfo) { r.add(prefix + "timeout") },
+		OnCallback: func(cxt context.Context, info *RunInfo, err error) {
+			r.add(prefix + "callback")
+			r.done <- err
+		},
+	}
+}
+
+func TestHooks(t *testing.T) {
+	admin, _ := newTestAdmin(t)
+	r := newHookRecorder()
+	e := newExecutor(ServerAddr(admin.URL), WithHooks(r.hooks("")))
+	e.Init()
+	e.RegTask("task.ok", noopTask("done"), TaskHooks(Hooks{
+		OnSuccess: func(cxt context.Context, info *RunInfo) {
+			assert.Equal(t, "task.ok", info.Handler)
+			assert.Equal(t, int64(200), info.Code)
+			assert.Assert(t, info.Duration >= 0)
+			r.add("handler:success")
+		},
+	}))
+	e.RegTask("task.panic", func(cxt context.Context, param *RunReq) string { panic("boom") })
+	e.RegTask("task.hung", func(cxt context.Context, param *RunReq) string {
+		time.Sleep(100 * time.Millisecond)
+		return ""
+	}, TaskTimeout(10*time.Millisecond))
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ok"})
+	assert.NilError(t, <-r.done)
+	assert.DeepEqual(t, []string{"start", "success:done", "handler:success", "callback"}, r.list())
+
+	r.events = nil
+	trigger(e, &RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.panic"})
+	assert.NilError(t, <-r.done)
+	assert.DeepEqual(t, []string{"start", "failure:500", "callback"}, r.list())
+
+	r.events = nil
+	trigger(e, &RunReq{JobID: 3, LogID: 3, ExecutorHandler: "task.hung"})
+	assert.NilError(t, <-r.done)
+	assert.DeepEqual(t, []string{"start", "timeout", "callback"}, r.list())
+}
+
+func TestHookKill(t *testing.T) {
+	admin, _ := newTestAdmin(t)
+	r := newHookRecorder()
+	e := newExecutor(ServerAddr(admin.URL), WithHooks(r.hooks("")))
+	e.Init()
+	started := make(chan struct{})
+	e.RegTask("task.ctx", func(cxt context.Context, param *RunReq) string {
+		close(started)
+		<-cxt.Done()
+		return "stopped"
+	})
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ctx"})
+	<-started
+	w := httptest.NewRecorder()
+	e.killTask(w, httptest.NewRequest("POST", "/kill", strings.NewReader(`{"jobId":1}`)))
+	assert.NilError(t, <-r.done)
+	assert.DeepEqual(t, []string{"start", "kill", "callback"}, r.list())
+}
+
+func TestHookKillNotBlocking(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	release := make(chan struct{})
+	e := newExecutor(ServerAddr(admin.URL), WithHooks(Hooks{
+		OnKill: func(cxt context.Context, info *RunInfo) { <-release },
+	}))
+	e.Init()
+	e.RegTask("task.ctx", func(cxt context.Context, param *RunReq) string {
+		<-cxt.Done()
+		return "stopped"
+	})
+	e.RegTask("task.ok", noopTask("done"))
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ctx"})
+	//耗时的OnKill不阻塞终止与其他调度
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w := httptest.NewRecorder()
+		e.killTask(w, httptest.NewRequest("POST", "/kill", strings.NewReader(`{"jobId":1}`)))
+		trigger(e, &RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.ok"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("kill blocked by OnKill hook")
+	}
+	assert.Equal(t, int64(2), (<-callbacks).LogID)
+	close(release)
+	assert.Equal(t, int64(1), (<-callbacks).LogID)
+}
+
+func TestHookPanicIsolated(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), WithHooks(Hooks{
+		OnStart:   func(cxt context.Context, info *RunInfo) { panic("start hook") },
+		OnSuccess: func(cxt context.Context, info *RunInfo) { panic("success hook") },
+	}))
+	e.Init()
+	e.RegTask("task.ok", noopTask("done"))
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ok"})
+	c := <-callbacks
+	assert.Equal(t, int64(200), c.ExecuteResult.Code)
+	assert.Equal(t, "done", c.ExecuteResult.Msg)
+}
+
+func TestHookCallbackError(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer admin.Close()
+	r := newHookRecorder()
+	e := newExecutor(ServerAddr(admin.URL), WithHooks(r.hooks("")))
+	e.Init()
+	e.RegTask("task.ok", noopTask("done"))
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ok"})
+	assert.ErrorContains(t, <-r.done, "502")
+}
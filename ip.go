@@ -0,0 +1,120 @@
+package xxl
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sort"
+)
+
+//默认覆盖执行器IP的环境变量
+var DefaultExecutorIPEnv = "XXL_EXECUTOR_IP"
+
+//网卡地址
+type InterfaceAddr struct {
+	Name  string    //网卡名称
+	Flags net.Flags //网卡状态
+	IP    net.IP    //地址
+}
+
+//列出本机网卡地址,可替换用于测试
+type InterfaceLister func() ([]InterfaceAddr, error)
+
+//执行器IP自动获取配置, 未设置ExecutorIp时使用
+//始终排除回环、链路本地及未启用网卡的地址, 偏好均不满足时使用剩余地址中的第一个
+type IPDiscovery struct {
+	Env        string          //覆盖IP的环境变量,为空时使用XXL_EXECUTOR_IP
+	Interface  string          //优先使用的网卡名称,如eth0
+	CIDRs      []string        //优先使用的网段,按顺序优先,如10.0.0.0/8
+	PreferIPv6 bool            //优先IPv6,默认优先IPv4
+	Lister     InterfaceLister //为nil时读取本机网卡
+}
+
+//获取执行器IP
+func (d IPDiscovery) Discover() (string, error) {
+	env := d.Env
+	if env == "" {
+		env = DefaultExecutorIPEnv
+	}
+	if ip := os.Getenv(env); ip != "" {
+		if net.ParseIP(ip) == nil {
+			return "", errors.New("invalid executor ip in $" + env + ": " + ip)
+		}
+		return ip, nil
+	}
+
+	nets := make([]*net.IPNet, 0, len(d.CIDRs))
+	for _, c := range d.CIDRs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return "", errors.New("invalid preferred cidr: " + err.Error())
+		}
+		nets = append(nets, n)
+	}
+	lister := d.Lister
+	if lister == nil {
+		lister = localInterfaceAddrs
+	}
+	addrs, err := lister()
+	if err != nil {
+		return "", errors.New("list interface addresses: " + err.Error())
+	}
+
+	var candidates []InterfaceAddr
+	for _, a := range addrs {
+		if a.Flags&net.FlagUp == 0 || a.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if a.IP.IsLoopback() || a.IP.IsLinkLocalUnicast() || a.IP.IsUnspecified() || a.IP.IsMulticast() {
+			continue
+		}
+		candidates = append(candidates, a)
+	}
+	if len(candidates) == 0 {
+		return "", errors.New("no usable executor ip found, set ExecutorIp or $" + env)
+	}
+
+	//分值越小越优先: 网卡 > 网段 > IP版本
+	score := func(a InterfaceAddr) (s int) {
+		if d.Interface != "" && a.Name != d.Interface {
+			s += 1 << 16
+		}
+		cidr := len(nets)
+		for i, n := range nets {
+			if n.Contains(a.IP) {
+				cidr = i
+				break
+			}
+		}
+		s += cidr << 1
+		if (a.IP.To4() == nil) != d.PreferIPv6 {
+			s++
+		}
+		return s
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return score(candidates[i]) < score(candidates[j])
+	})
+	return candidates[0].IP.String(), nil
+}
+
+//读取本机网卡地址
+func localInterfaceAddrs() ([]InterfaceAddr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var res []InterfaceAddr
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok {
+				res = append(res, InterfaceAddr{Name: iface.Name, Flags: iface.Flags, IP: ipnet.IP})
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package xxl
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestIPDiscovery(t *testing.T) {
+	up := net.FlagUp
+	addrs := []InterfaceAddr{
+		{Name: "lo", Flags: up | net.FlagLoopback, IP: net.ParseIP("127.0.0.1")},
+		{Name: "docker0", Flags: up, IP: net.ParseIP("172.17.0.1")},
+		{Name: "eth0", Flags: up, IP: net.ParseIP("fe80::1")},
+		{Name: "eth0", Flags: up, IP: net.ParseIP("2001:db8::10")},
+		{Name: "eth0", Flags: up, IP: net.ParseIP("10.1.2.3")},
+		{Name: "tun0", Flags: up, IP: net.ParseIP("192.168.100.5")},
+		{Name: "eth1", Flags: 0, IP: net.ParseIP("10.9.9.9")},
+	}
+	lister := func() ([]InterfaceAddr, error) { return addrs, nil }
+
+	cases := []struct {
+		name string
+		d    IPDiscovery
+		want string
+	}{
+		{"first usable ipv4", IPDiscovery{}, "172.17.0.1"},
+		{"interface", IPDiscovery{Interface: "eth0"}, "10.1.2.3"},
+		{"interface ipv6", IPDiscovery{Interface: "eth0", PreferIPv6: true}, "2001:db8::10"},
+		{"cidr order", IPDiscovery{CIDRs: []string{"192.168.0.0/16", "10.0.0.0/8"}}, "192.168.100.5"},
+		{"interface before cidr", IPDiscovery{Interface: "eth0", CIDRs: []string{"192.168.0.0/16"}}, "10.1.2.3"},
+		{"missing interface falls back", IPDiscovery{Interface: "wlan0", CIDRs: []string{"10.0.0.0/8"}}, "10.1.2.3"},
+		{"prefer ipv6", IPDiscovery{PreferIPv6: true}, "2001:db8::10"},
+	}
+	for _, c := range cases {
+		c.d.Lister = lister
+		c.d.Env = "XXL_TEST_EXECUTOR_IP_UNSET"
+		got, err := c.d.Discover()
+		assert.NilError(t, err, c.name)
+		assert.Equal(t, c.want, got, c.name)
+	}
+
+	_, err := IPDiscovery{Lister: func() ([]InterfaceAddr, error) { return addrs[:1], nil }}.Discover()
+	assert.ErrorContains(t, err, "no usable executor ip")
+	_, err = IPDiscovery{Lister: func() ([]InterfaceAddr, error) { return nil, errors.New("boom") }}.Discover()
+	assert.ErrorContains(t, err, "boom")
+	_, err = IPDiscovery{Lister: lister, CIDRs: []string{"10.0.0.0"}}.Discover()
+	assert.ErrorContains(t, err, "invalid preferred cidr")
+}
+
+func TestIPDiscoveryEnv(t *testing.T) {
+	assert.NilError(t, os.Setenv("XXL_TEST_EXECUTOR_IP", "10.10.10.10"))
+	defer os.Unsetenv("XXL_TEST_EXECUTOR_IP")
+	d := IPDiscovery{Env: "XXL_TEST_EXECUTOR_IP", Lister: func() ([]InterfaceAddr, error) { return nil, nil }}
+	got, err := d.Discover()
+	assert.NilError(t, err)
+	assert.Equal(t, "10.10.10.10", got)
+
+	assert.NilError(t, os.Setenv("XXL_TEST_EXECUTOR_IP", "not-an-ip"))
+	_, err = d.Discover()
+	assert.ErrorContains(t, err, "invalid executor ip")
+}
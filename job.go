@@ -1,10 +1,9 @@
 package xxl
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+
 	"github.com/fatih/structs"
-	"io/ioutil"
 )
 
 var jobPathPrefix string = "/jobinfo"
@@ -106,82 +105,69 @@ type RespAddJob struct {
 	Content string `json:"content"`
 }
 
+//动态增加一个任务,json格式
 func (e *executor) AddJob(taskInfo AddJobInfo) (respBody []byte, err error) {
-	param, err := json.Marshal(taskInfo)
-	if err != nil {
-		e.log.Error("[err]AddJob:" + err.Error())
-		return
-	}
-	res, err := e.post(addJobPath, string(param))
-	//e.log.Info("任务增加ready:" + string(param))
-	if err != nil {
-		e.log.Error("[err]AddJob err : ", err.Error())
-		return
-	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		e.log.Error("[err]AddJob: ReadAll err : ", err.Error())
-		return
-	}
-	defer res.Body.Close()
-	e.log.Info("任务增加成功:" + string(body))
-	return body, err
+	return e.AddJobContext(context.Background(), taskInfo)
 }
 
-func (e *executor) StopJob(jobID int) {
-	param, err := json.Marshal(map[string]interface{}{"id": jobID})
+//动态增加一个任务,json格式,可取消
+func (e *executor) AddJobContext(cxt context.Context, taskInfo AddJobInfo) (respBody []byte, err error) {
+	e.logInfo(MsgJobAdd, "handler", taskInfo.ExecutorHandler)
+	respBody, err = e.postJSON(cxt, addJobPath, taskInfo)
 	if err != nil {
-		e.log.Error("[err]StopJob param:" + err.Error())
+		e.logError(MsgJobAddFailed, "handler", taskInfo.ExecutorHandler, "err", err)
 		return
 	}
-	res, err := e.post(stopJobPath, string(param))
+	e.logInfo(MsgJobAddOK, "handler", taskInfo.ExecutorHandler, "body", string(respBody))
+	return
+}
+
+//停止一个任务,表单提交id;不兼容变更:之前为json提交且无返回值
+func (e *executor) StopJob(jobID int) (respBody []byte, err error) {
+	return e.StopJobContext(context.Background(), jobID)
+}
+
+//停止一个任务,可取消
+func (e *executor) StopJobContext(cxt context.Context, jobID int) (respBody []byte, err error) {
+	respBody, err = e.postForm(cxt, stopJobPath, map[string]interface{}{"id": jobID})
 	if err != nil {
-		e.log.Error("[err]StopJob err : ", err.Error())
+		e.logError(MsgJobStopFailed, "jobId", jobID, "err", err)
 		return
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		e.log.Error("[err]StopJob: ReadAll err : ", err.Error())
-	}
-	e.log.Info("任务停止成功:" + string(body))
+	e.logInfo(MsgJobStopOK, "jobId", jobID, "body", string(respBody))
+	return
 }
 
 //启动一个任务
 func (e *executor) StartJob(jobID string) (respBody []byte, err error) {
-	param := map[string]interface{}{"id": fmt.Sprint(jobID)}
-	res, err := e.postForm(StartJobPath, param)
-	e.log.Info("任务增加 StartJob:", param)
+	return e.StartJobContext(context.Background(), jobID)
+}
+
+//启动一个任务,可取消
+func (e *executor) StartJobContext(cxt context.Context, jobID string) (respBody []byte, err error) {
+	e.logInfo(MsgJobStart, "jobId", jobID)
+	respBody, err = e.postForm(cxt, StartJobPath, map[string]interface{}{"id": jobID})
 	if err != nil {
-		e.log.Error("[err]StartJob err : ", err.Error())
+		e.logError(MsgJobStartFailed, "jobId", jobID, "err", err)
 		return
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		e.log.Error("[err]StartJob: ReadAll err : ", err.Error())
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	e.log.Info("任务启动成功:" + string(body))
-	return body, nil
+	e.logInfo(MsgJobStartOK, "jobId", jobID, "body", string(respBody))
+	return
 }
 
 //AddJobByPostForm 动态增加任务
 func (e *executor) AddJobByPostForm(taskInfo AddJobInfo) (respBody []byte, err error) {
-	param := structs.Map(taskInfo)
-	res, err := e.postForm(addJobPath, param)
-	e.log.Info("任务增加 AddJobByPostForm:", param)
+	return e.AddJobByPostFormContext(context.Background(), taskInfo)
+}
 
+//AddJobByPostFormContext 动态增加任务,可取消
+func (e *executor) AddJobByPostFormContext(cxt context.Context, taskInfo AddJobInfo) (respBody []byte, err error) {
+	e.logInfo(MsgJobAdd, "handler", taskInfo.ExecutorHandler)
+	respBody, err = e.postForm(cxt, addJobPath, structs.Map(taskInfo))
 	if err != nil {
-		e.log.Error("[err]AddJobByPostForm err : ", err.Error())
-		return
-	}
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		e.log.Error("[err]AddJobByPostForm: ReadAll err : ", err.Error())
+		e.logError(MsgJobAddFailed, "handler", taskInfo.ExecutorHandler, "err", err)
 		return
 	}
-	e.log.Info("任务增加成功:" + string(body))
-	defer res.Body.Close()
-	return body, err
+	e.logInfo(MsgJobAddOK, "handler", taskInfo.ExecutorHandler, "body", string(respBody))
+	return
 }
@@ -1,38 +1,95 @@
-package xxl
+package xxl_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+	"github.com/konglong87/xxl-job-executor-go/xxltest"
+	"gotest.tools/assert"
 )
 
 func TestExecutor_AddJobByPostForm(t *testing.T) {
-	taskInfo := AddJobInfo{
+	admin := xxltest.NewAdmin(t)
+	taskInfo := xxl.AddJobInfo{
 		JobGroupID:             2,
 		JobDesc:                "oaa-service" + "_" + fmt.Sprintf("task_id_%d_%d", 87, time.Now().Unix()),
-		ExecutorRouteStrategy:  FirstExecutorRouteStrategyType,
-		CronGenDisplay:         FormatTimeToCronTab(time.Now().Add(20 * time.Minute)),
-		JobCron:                FormatTimeToCronTab(time.Now().Add(20 * time.Minute)),
+		ExecutorRouteStrategy:  xxl.FirstExecutorRouteStrategyType,
+		CronGenDisplay:         xxl.FormatTimeToCronTab(time.Now().Add(20 * time.Minute)),
+		JobCron:                xxl.FormatTimeToCronTab(time.Now().Add(20 * time.Minute)),
 		ChildJobId:             "",
 		Author:                 "yyg",
 		AlarmEmail:             "",
 		ExecutorHandler:        "runTaskHandler",
 		ExecutorParams:         "{\"id\":12345}",
-		ExecutorBlockStrategy:  SerialExecutionBlockStrategy,
+		ExecutorBlockStrategy:  xxl.SerialExecutionBlockStrategy,
 		ExecutorTimeout:        0,
 		ExecutorFailRetryCount: 0,
 		GlueType:               "BEAN",
 		ScheduleType:           "CRON",
-		ScheduleConf:           FormatTimeToCronTab(time.Now().Add(20 * time.Minute)),
-		MisfireStrategy:        MisfireStrategyNothing,
+		ScheduleConf:           xxl.FormatTimeToCronTab(time.Now().Add(20 * time.Minute)),
+		MisfireStrategy:        xxl.MisfireStrategyNothing,
 	}
-	ne := NewExecutor(
-		ServerAddr("https://tms.testing.pipacoding.com/xxl-job-new"),
-		RegistryKey("runTaskHandler"),
-		AccessToken("xxl-job-new-testing"),
+	ne := xxl.NewExecutor(
+		xxl.ServerAddr(admin.URL),
+		xxl.RegistryKey("runTaskHandler"),
+		xxl.AccessToken("xxl-job-new-testing"),
 	)
 	ne.Init()
 	ex, err := ne.AddJobByPostForm(taskInfo)
-	t.Log(err)
-	t.Log(string(ex))
+	assert.NilError(t, err)
+	assert.Equal(t, `{"code":200,"content":"1","msg":null}`, string(ex))
+
+	jobs := admin.Jobs()
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, taskInfo.JobDesc, jobs[0].Params["jobDesc"])
+	assert.Equal(t, "runTaskHandler", jobs[0].Params["executorHandler"])
+	assert.Equal(t, "{\"id\":12345}", jobs[0].Params["executorParam"])
+	assert.Equal(t, "SERIAL_EXECUTION", jobs[0].Params["executorBlockStrategy"])
+	assert.Equal(t, taskInfo.ScheduleConf, jobs[0].Params["scheduleConf"])
+}
+
+func TestAdminAPIContext(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	ne := xxl.NewExecutor(xxl.ServerAddr(admin.URL))
+	ne.Init()
+
+	_, err := ne.AddJobContext(context.Background(), xxl.AddJobInfo{ExecutorHandler: "task.a"})
+	assert.NilError(t, err)
+	_, err = ne.StartJobContext(context.Background(), "1")
+	assert.NilError(t, err)
+	assert.Assert(t, admin.Jobs()[0].Running)
+	_, err = ne.StopJobContext(context.Background(), 1)
+	assert.NilError(t, err)
+	assert.Assert(t, !admin.Jobs()[0].Running)
+
+	//超时取消
+	admin.Inject("/jobinfo/stop", xxltest.Fault{Latency: time.Second})
+	cxt, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = ne.StopJobContext(cxt, 1)
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Assert(t, time.Since(start) < time.Second)
+
+	//http状态码错误
+	admin.Inject("/jobinfo/add", xxltest.Fault{Status: http.StatusBadGateway, Body: "bad gateway", Times: 1})
+	body, err := ne.AddJobByPostFormContext(context.Background(), xxl.AddJobInfo{ExecutorHandler: "task.b"})
+	adminErr := &xxl.AdminError{}
+	assert.Assert(t, errors.As(err, &adminErr))
+	assert.Equal(t, http.StatusBadGateway, adminErr.Status)
+	assert.Equal(t, "bad gateway", adminErr.Msg)
+	assert.Equal(t, "bad gateway", string(body))
+
+	//未登录时调度中心返回登录页面
+	admin.Inject("/jobinfo/start", xxltest.Fault{Body: "<html>login</html>", Times: 1})
+	_, err = ne.StartJob("1")
+	assert.Assert(t, errors.As(err, &adminErr))
+	assert.Equal(t, http.StatusOK, adminErr.Status)
+	assert.Equal(t, int64(0), adminErr.Code)
+	assert.Equal(t, "<html>login</html>", adminErr.Msg)
 }
@@ -0,0 +1,165 @@
+package xxl
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+//超过并发限制时的处理策略
+type LimitPolicy int
+
+const (
+	LimitReject LimitPolicy = iota //直接拒绝,返回500执行器繁忙;调度中心忙碌转移(BUSYOVER)通过/idleBeat在全局并发已满时转到其他执行器
+	LimitQueue                     //排队等待,超过队列长度时拒绝
+)
+
+//并发使用情况
+type ConcurrencyStats struct {
+	Running        int                           `json:"running"`        // 正在执行的任务数
+	Queued         int                           `json:"queued"`         // 排队中的任务数
+	Abandoned      int                           `json:"abandoned"`      // 超时已回调但任务函数仍在运行的任务数,仍占用执行名额
+	MaxConcurrency int                           `json:"maxConcurrency"` // 全局并发限制,0为不限制
+	QueueSize      int                           `json:"queueSize"`      // 排队长度
+	Handlers       map[string]HandlerConcurrency `json:"handlers"`       // 设置了并发限制的任务
+}
+
+//任务并发使用情况
+type HandlerConcurrency struct {
+	Running int `json:"running"` // 正在执行数
+	Limit   int `json:"limit"`   // 并发限制
+}
+
+//任务并发限制
+type limiter struct {
+	policy    LimitPolicy
+	queueSize int
+	global    chan struct{}            //全局信号量,nil为不限制
+	handlers  map[string]chan struct{} //任务信号量,只读
+	running   int64
+	queued    int64
+	abandoned int64      //超时后仍在运行
+	mu        sync.Mutex //排队计数的检查与修改,读取使用atomic
+}
+
+func newLimiter(max int, handlers map[string]int, policy LimitPolicy, queueSize int) *limiter {
+	l := &limiter{
+		policy:    policy,
+		queueSize: queueSize,
+		handlers:  make(map[string]chan struct{}, len(handlers)),
+	}
+	if max > 0 {
+		l.global = make(chan struct{}, max)
+	}
+	for name, n := range handlers {
+		if n > 0 {
+			l.handlers[name] = make(chan struct{}, n)
+		}
+	}
+	return l
+}
+
+//调度时检查, acquired为true时已获得执行名额; 排队时acquired为false,需要调用wait; ok为false时拒绝;
+//cover为true时(coverEarly覆盖正在执行的任务)不拒绝,排队等待被终止的任务释放名额
+func (l *limiter) admit(name string, cover bool) (acquired, ok bool) {
+	if l.tryAcquire(name) {
+		return true, true
+	}
+	if l.policy != LimitQueue && !cover {
+		return false, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if int(atomic.LoadInt64(&l.queued)) >= l.queueSize && !cover {
+		return false, false
+	}
+	atomic.AddInt64(&l.queued, 1)
+	return false, true
+}
+
+//排队等待执行名额,先获取任务名额再获取全局名额,等待任务名额时不占用全局名额
+func (l *limiter) wait(cxt context.Context, name string) error {
+	defer l.dequeue()
+	h := l.handlers[name]
+	if h != nil {
+		select {
+		case h <- struct{}{}:
+		case <-cxt.Done():
+			return cxt.Err()
+		}
+	}
+	if l.global != nil {
+		select {
+		case l.global <- struct{}{}:
+		case <-cxt.Done():
+			if h != nil {
+				<-h
+			}
+			return cxt.Err()
+		}
+	}
+	atomic.AddInt64(&l.running, 1)
+	return nil
+}
+
+//离开排队
+func (l *limiter) dequeue() {
+	l.mu.Lock()
+	atomic.AddInt64(&l.queued, -1)
+	l.mu.Unlock()
+}
+
+func (l *limiter) tryAcquire(name string) bool {
+	h := l.handlers[name]
+	if h != nil {
+		select {
+		case h <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	if l.global != nil {
+		select {
+		case l.global <- struct{}{}:
+		default:
+			if h != nil {
+				<-h
+			}
+			return false
+		}
+	}
+	atomic.AddInt64(&l.running, 1)
+	return true
+}
+
+//释放执行名额
+func (l *limiter) release(name string) {
+	atomic.AddInt64(&l.running, -1)
+	if h := l.handlers[name]; h != nil {
+		<-h
+	}
+	if l.global != nil {
+		<-l.global
+	}
+}
+
+//全局并发是否已满,任务并发限制需要任务名称,忙碌检测时不判断
+func (l *limiter) saturated() bool {
+	return l.global != nil && len(l.global) >= cap(l.global)
+}
+
+//使用情况
+func (l *limiter) stats() ConcurrencyStats {
+	s := ConcurrencyStats{
+		Running:        int(atomic.LoadInt64(&l.running)),
+		Queued:         int(atomic.LoadInt64(&l.queued)),
+		Abandoned:      int(atomic.LoadInt64(&l.abandoned)),
+		MaxConcurrency: cap(l.global),
+		QueueSize:      l.queueSize,
+		Handlers:       make(map[string]HandlerConcurrency, len(l.handlers)),
+	}
+	for name, h := range l.handlers {
+		s.Handlers[name] = HandlerConcurrency{Running: len(h), Limit: cap(h)}
+	}
+	return s
+}
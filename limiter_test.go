@@ -0,0 +1,255 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+//阻塞任务,记录最大并发
+type blockingTask struct {
+	running, max int64
+	release      chan struct{}
+}
+
+func (b *blockingTask) fn(cxt context.Context, param *RunReq) string {
+	n := atomic.AddInt64(&b.running, 1)
+	for {
+		m := atomic.LoadInt64(&b.max)
+		if n <= m || atomic.CompareAndSwapInt64(&b.max, m, n) {
+			break
+		}
+	}
+	defer atomic.AddInt64(&b.running, -1)
+	select {
+	case <-b.release:
+	case <-cxt.Done():
+	}
+	return "done"
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+	}
+}
+
+func TestConcurrencyReject(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), MaxConcurrency(2))
+	e.Init()
+	b := &blockingTask{release: make(chan struct{})}
+	e.RegTask("task.block", b.fn)
+
+	var wg sync.WaitGroup
+	results := make(chan *callElement, 5)
+	for i := int64(1); i <= 5; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			results <- trigger(e, &RunReq{JobID: id, LogID: id, ExecutorHandler: "task.block"})
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+	var accepted, busy int
+	for r := range results {
+		if r.ExecuteResult.Code == 200 {
+			accepted++
+		} else {
+			busy++
+			assert.Assert(t, strings.HasPrefix(r.ExecuteResult.Msg.(string), "[EXECUTOR_BUSY]"))
+		}
+	}
+	assert.Equal(t, 2, accepted)
+	assert.Equal(t, 3, busy)
+	waitFor(t, func() bool { return atomic.LoadInt64(&b.running) == 2 })
+	assert.Equal(t, 2, e.Concurrency().Running)
+
+	close(b.release)
+	for i := 0; i < 2; i++ {
+		<-callbacks
+	}
+	waitFor(t, func() bool { return e.Concurrency().Running == 0 })
+	assert.Equal(t, int64(2), b.max)
+}
+
+func TestConcurrencyQueue(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), MaxConcurrency(3), HandlerMaxConcurrency("task.block", 1), ConcurrencyPolicy(LimitQueue, 2))
+	e.Init()
+	b := &blockingTask{release: make(chan struct{})}
+	e.RegTask("task.block", b.fn)
+	e.RegTask("task.other", func(cxt context.Context, param *RunReq) string { return "ok" })
+
+	for i := int64(1); i <= 3; i++ {
+		assert.Equal(t, int64(200), trigger(e, &RunReq{JobID: i, LogID: i, ExecutorHandler: "task.block"}).ExecuteResult.Code)
+	}
+	//队列已满
+	r := trigger(e, &RunReq{JobID: 4, LogID: 4, ExecutorHandler: "task.block"})
+	assert.Equal(t, int64(500), r.ExecuteResult.Code)
+	waitFor(t, func() bool { return e.Concurrency().Queued == 2 && e.Concurrency().Running == 1 })
+	waitParked(t, 2)
+	assert.DeepEqual(t, HandlerConcurrency{Running: 1, Limit: 1}, e.Concurrency().Handlers["task.block"])
+	//排队中的任务不占用全局名额
+	assert.Equal(t, 1, len(e.limiter.global))
+
+	//其他任务不受task.block的限制
+	assert.Equal(t, int64(200), trigger(e, &RunReq{JobID: 5, LogID: 5, ExecutorHandler: "task.other"}).ExecuteResult.Code)
+	assert.Equal(t, int64(5), (<-callbacks).LogID)
+
+	//排队中被终止
+	e.runList.Get("3").Cancel()
+	c := <-callbacks
+	assert.Equal(t, int64(3), c.LogID)
+	assert.Assert(t, strings.HasPrefix(c.ExecuteResult.Msg.(string), "[TASK_CANCELED]"))
+
+	close(b.release)
+	<-callbacks
+	<-callbacks
+	assert.Equal(t, int64(1), b.max)
+	waitFor(t, func() bool { s := e.Concurrency(); return s.Running == 0 && s.Queued == 0 })
+}
+
+//等待n个排队中的调度阻塞在limiter.wait
+func waitParked(t *testing.T, n int) {
+	waitFor(t, func() bool {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		parked := 0
+		for _, g := range strings.Split(string(buf), "\n\n") {
+			header := strings.SplitN(g, "\n", 2)[0]
+			if strings.Contains(g, "(*limiter).wait(") && strings.Contains(header, "[select") {
+				parked++
+			}
+		}
+		return parked == n
+	})
+}
+
+func TestConcurrencyPattern(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), HandlerMaxConcurrency("report.*", 1))
+	e.Init()
+	b := &blockingTask{release: make(chan struct{})}
+	e.RegTask("report.*", b.fn)
+
+	//匹配同一pattern的任务共同限制
+	assert.Equal(t, int64(200), trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "report.daily"}).ExecuteResult.Code)
+	for i := int64(2); i <= 3; i++ {
+		r := trigger(e, &RunReq{JobID: i, LogID: i, ExecutorHandler: "report.daily"})
+		assert.Assert(t, strings.HasPrefix(r.ExecuteResult.Msg.(string), "[EXECUTOR_BUSY]"))
+	}
+	r := trigger(e, &RunReq{JobID: 4, LogID: 4, ExecutorHandler: "report.weekly"})
+	assert.Assert(t, strings.HasPrefix(r.ExecuteResult.Msg.(string), "[EXECUTOR_BUSY]"))
+	assert.DeepEqual(t, HandlerConcurrency{Running: 1, Limit: 1}, e.Concurrency().Handlers["report.*"])
+
+	close(b.release)
+	<-callbacks
+	waitFor(t, func() bool { return e.Concurrency().Running == 0 })
+}
+
+func TestConcurrencyCoverEarly(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), HandlerMaxConcurrency("task.block", 1))
+	e.Init()
+	b := &blockingTask{release: make(chan struct{})}
+	e.RegTask("task.block", b.fn)
+
+	assert.Equal(t, int64(200), trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.block"}).ExecuteResult.Code)
+	waitFor(t, func() bool { return atomic.LoadInt64(&b.running) == 1 })
+	//其他JobID超过限制被拒绝,覆盖同一JobID时终止之前的调度并等待名额
+	r := trigger(e, &RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.block"})
+	assert.Assert(t, strings.HasPrefix(r.ExecuteResult.Msg.(string), "[EXECUTOR_BUSY]"))
+	r = trigger(e, &RunReq{JobID: 1, LogID: 3, ExecutorHandler: "task.block", ExecutorBlockStrategy: coverEarly})
+	assert.Equal(t, int64(200), r.ExecuteResult.Code)
+
+	c := <-callbacks
+	assert.Equal(t, int64(1), c.LogID)
+	waitFor(t, func() bool { return atomic.LoadInt64(&b.running) == 1 && e.runList.Exists("1") })
+	assert.Equal(t, int64(1), atomic.LoadInt64(&b.max))
+	close(b.release)
+	c = <-callbacks
+	assert.Equal(t, int64(3), c.LogID)
+	assert.Equal(t, int64(200), c.ExecuteResult.Code)
+	waitFor(t, func() bool { return e.Concurrency().Running == 0 && e.Concurrency().Queued == 0 })
+}
+
+func TestLimiterQueueRace(t *testing.T) {
+	l := newLimiter(1, nil, LimitQueue, 3)
+	//占用唯一的名额,所有调度都进入排队检查
+	assert.Assert(t, l.tryAcquire("task.a"))
+	var (
+		wg       sync.WaitGroup
+		admitted int64
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				acquired, ok := l.admit("task.a", false)
+				if !ok {
+					continue
+				}
+				atomic.AddInt64(&admitted, 1)
+				if !acquired {
+					cxt, cancel := context.WithTimeout(context.Background(), time.Microsecond)
+					err := l.wait(cxt, "task.a")
+					cancel()
+					if err != nil {
+						continue
+					}
+				}
+				l.release("task.a")
+			}
+		}()
+	}
+	wg.Wait()
+	l.release("task.a")
+	assert.Assert(t, atomic.LoadInt64(&admitted) > 0)
+	assert.Equal(t, 0, l.stats().Queued)
+	assert.Equal(t, 0, l.stats().Running)
+	assert.Equal(t, 0, len(l.global))
+}
+
+func TestIdleBeat(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), MaxConcurrency(1))
+	e.Init()
+	b := &blockingTask{release: make(chan struct{})}
+	e.RegTask("task.block", b.fn)
+	beat := func(path string, jobID int64) *res {
+		w := httptest.NewRecorder()
+		e.handler().ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(`{"jobId":`+Int64ToStr(jobID)+`}`)))
+		r := &res{}
+		assert.NilError(t, json.Unmarshal(w.Body.Bytes(), r))
+		return r
+	}
+
+	assert.Equal(t, int64(200), beat("/beat", 0).Code)
+	assert.Equal(t, int64(200), beat("/idleBeat", 1).Code)
+	assert.Equal(t, int64(200), trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.block"}).ExecuteResult.Code)
+	r := beat("/idleBeat", 1)
+	assert.Equal(t, int64(500), r.Code)
+	assert.Assert(t, strings.HasPrefix(r.Msg.(string), "[TASK_RUNNING]"))
+	r = beat("/idleBeat", 2)
+	assert.Equal(t, int64(500), r.Code)
+	assert.Assert(t, strings.HasPrefix(r.Msg.(string), "[EXECUTOR_BUSY]"))
+
+	close(b.release)
+	<-callbacks
+	waitFor(t, func() bool { return e.Concurrency().Running == 0 })
+	assert.Equal(t, int64(200), beat("/idleBeat", 2).Code)
+}
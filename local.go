@@ -0,0 +1,90 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+/**
+本地触发任务,不需要调度中心,用于调试与测试:
+	res := exec.TriggerLocal("task.test", "id=1", xxl.TriggerTimeout(time.Minute))
+与调度中心触发一样经过阻塞策略、并发限制、超时、钩子与执行记录,同步返回结果,不回调调度中心
+未设置TriggerJobID时每次使用不同的负数JobID,不会与调度中心的任务互相阻塞
+*/
+
+//本地触发选项
+type TriggerOption func(req *RunReq)
+
+//任务ID,相同JobID按阻塞策略处理
+func TriggerJobID(id int64) TriggerOption {
+	return func(req *RunReq) {
+		req.JobID = id
+	}
+}
+
+//调度日志ID,默认与JobID相同
+func TriggerLogID(id int64) TriggerOption {
+	return func(req *RunReq) {
+		req.LogID = id
+	}
+}
+
+//阻塞策略,SERIAL_EXECUTION、DISCARD_LATER、COVER_EARLY
+func TriggerBlockStrategy(strategy string) TriggerOption {
+	return func(req *RunReq) {
+		req.ExecutorBlockStrategy = strategy
+	}
+}
+
+//超时时间,按秒向上取整
+func TriggerTimeout(d time.Duration) TriggerOption {
+	return func(req *RunReq) {
+		req.ExecutorTimeout = int64((d + time.Second - 1) / time.Second)
+	}
+}
+
+//分片参数
+func TriggerBroadcast(index, total int64) TriggerOption {
+	return func(req *RunReq) {
+		req.BroadcastIndex = index
+		req.BroadcastTotal = total
+	}
+}
+
+//本地触发的JobID序号
+var localSeq int64
+
+//本地触发任务,同步返回结果
+func (e *executor) TriggerLocal(handler, params string, opts ...TriggerOption) Result {
+	param := &RunReq{
+		ExecutorHandler: handler,
+		ExecutorParams:  params,
+		LogDateTime:     time.Now().UnixMilli(),
+	}
+	for _, o := range opts {
+		o(param)
+	}
+	if param.JobID == 0 {
+		param.JobID = -atomic.AddInt64(&localSeq, 1)
+	}
+	if param.LogID == 0 {
+		param.LogID = param.JobID
+	}
+	e.logInfo(MsgTaskParams, runFields(param, "params", param.ExecutorParams, "blockStrategy", param.ExecutorBlockStrategy, "timeout", param.ExecutorTimeout, "local", true)...)
+	cxt, span := e.tracer.Start(context.Background(), "xxl.local "+handler,
+		trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(runAttributes(param)...))
+	done := make(chan Result, 1)
+	e.mu.Lock()
+	code, msg := e.dispatch(cxt, span, param, func(cxt context.Context, task *Task, code int64, msg string) {
+		done <- Result{Code: code, Msg: msg}
+	})
+	e.mu.Unlock()
+	if code != http.StatusOK {
+		return Result{Code: code, Msg: msg}
+	}
+	return <-done
+}
@@ -0,0 +1,76 @@
+package xxl
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestTriggerLocal(t *testing.T) {
+	//没有调度中心,回调会失败,本地触发不应回调
+	e := newExecutor(ServerAddr("http://127.0.0.1:1"), Language(LangEn))
+	e.Init()
+	h := newHookRecorder()
+	e.RegTask("task.echo", func(cxt context.Context, param *RunReq) string {
+		return param.ExecutorParams
+	}, TaskHooks(h.hooks("")))
+	e.RegTask("task.slow", func(cxt context.Context, param *RunReq) string {
+		<-cxt.Done()
+		return "canceled"
+	})
+
+	res := e.TriggerLocal("task.echo", "id=1")
+	assert.Equal(t, int64(200), res.Code)
+	assert.Equal(t, "id=1", res.Msg)
+	list := e.History(HistoryQuery{Handler: "task.echo"})
+	assert.Equal(t, 1, len(list))
+	assert.Assert(t, list[0].JobID < 0)
+	assert.Equal(t, list[0].JobID, list[0].LogID)
+	assert.Assert(t, !e.runList.Exists(Int64ToStr(list[0].JobID)))
+	assert.DeepEqual(t, []string{"start", "success:id=1"}, h.list())
+
+	res = e.TriggerLocal("task.missing", "")
+	assert.Equal(t, int64(500), res.Code)
+	assert.Assert(t, strings.Contains(res.Msg, "[TASK_NOT_REGISTERED]"))
+
+	start := time.Now()
+	res = e.TriggerLocal("task.slow", "", TriggerTimeout(time.Millisecond))
+	assert.Equal(t, int64(502), res.Code)
+	assert.Assert(t, time.Since(start) < 2*time.Second)
+}
+
+func TestTriggerLocalBlockStrategy(t *testing.T) {
+	e := newExecutor(ServerAddr("http://127.0.0.1:1"), Language(LangEn))
+	e.Init()
+	started := make(chan struct{}, 2)
+	e.RegTask("task.wait", func(cxt context.Context, param *RunReq) string {
+		started <- struct{}{}
+		<-cxt.Done()
+		return "stopped " + param.ExecutorParams
+	})
+
+	first := make(chan Result, 1)
+	go func() { first <- e.TriggerLocal("task.wait", "a", TriggerJobID(9)) }()
+	<-started
+	res := e.TriggerLocal("task.wait", "b", TriggerJobID(9))
+	assert.Equal(t, int64(500), res.Code)
+	assert.Assert(t, strings.Contains(res.Msg, "[TASK_RUNNING]"))
+
+	second := make(chan Result, 1)
+	go func() { second <- e.TriggerLocal("task.wait", "c", TriggerJobID(9), TriggerBlockStrategy(coverEarly)) }()
+	assert.Equal(t, "stopped a", (<-first).Msg)
+	<-started
+	assert.Assert(t, e.kill(9))
+	assert.Equal(t, "stopped c", (<-second).Msg)
+}
+
+func TestTriggerOptions(t *testing.T) {
+	req := &RunReq{}
+	for _, o := range []TriggerOption{TriggerJobID(3), TriggerLogID(4), TriggerTimeout(1500 * time.Millisecond), TriggerBroadcast(1, 2)} {
+		o(req)
+	}
+	assert.DeepEqual(t, &RunReq{JobID: 3, LogID: 4, ExecutorTimeout: 2, BroadcastIndex: 1, BroadcastTotal: 2}, req)
+}
@@ -3,6 +3,7 @@ package xxl
 import (
 	"fmt"
 	"log"
+	"strings"
 )
 
 //应用日志
@@ -24,3 +25,70 @@ func (l *logger) Info(format string, a ...interface{}) {
 func (l *logger) Error(format string, a ...interface{}) {
 	log.Println(fmt.Sprintf(format, a...))
 }
+
+//结构化分级日志, kv为成对的key、value,如 "jobId", 1, "logId", 2
+//执行器输出的字段: jobId、logId、handler、err
+type StructuredLogger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+//将Logger适配为StructuredLogger, Debug、Info输出到Info, Warn、Error输出到Error, 字段以key=value附加在消息后
+func FromLogger(l Logger) StructuredLogger {
+	return &legacyLogger{l: l}
+}
+
+type legacyLogger struct {
+	l Logger
+}
+
+func (l *legacyLogger) Debug(msg string, kv ...interface{}) {
+	l.l.Info("%s", formatKV(msg, kv))
+}
+
+func (l *legacyLogger) Info(msg string, kv ...interface{}) {
+	l.l.Info("%s", formatKV(msg, kv))
+}
+
+func (l *legacyLogger) Warn(msg string, kv ...interface{}) {
+	l.l.Error("%s", formatKV(msg, kv))
+}
+
+func (l *legacyLogger) Error(msg string, kv ...interface{}) {
+	l.l.Error("%s", formatKV(msg, kv))
+}
+
+//消息 key=value key=value
+func formatKV(msg string, kv []interface{}) string {
+	if len(kv) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		b.WriteByte(' ')
+		b.WriteString(fmt.Sprint(kv[i]))
+		b.WriteByte('=')
+		if i+1 >= len(kv) {
+			b.WriteString("<missing>")
+			break
+		}
+		v := fmt.Sprint(kv[i+1])
+		if v == "" || strings.ContainsAny(v, " =\"\n") {
+			v = fmt.Sprintf("%q", v)
+		}
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+//调度日志字段
+func runFields(param *RunReq, kv ...interface{}) []interface{} {
+	return append([]interface{}{
+		"jobId", param.JobID,
+		"logId", param.LogID,
+		"handler", param.ExecutorHandler,
+	}, kv...)
+}
@@ -0,0 +1,68 @@
+package xxl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+/**
+过期日志清理,与java执行器相同:
+LogDir下按日期(2006-01-02)命名的目录超过LogRetentionDays天后删除,其他文件与目录不会删除;
+LogRetentionDays小于3时不清理
+*/
+
+//日志最少保存天数
+const minLogRetentionDays = 3
+
+//删除dir下超过days天的日期目录,返回删除的目录
+func cleanLogDir(dir string, days int, now time.Time) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	expire := today.AddDate(0, 0, -days)
+	var removed []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", entry.Name(), time.Local)
+		if err != nil || !day.Before(expire) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+//每天清理一次过期日志
+func (e *executor) cleanLogs() {
+	t := time.NewTimer(0)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-e.cxt.Done():
+			return
+		}
+		t.Reset(24 * time.Hour)
+		if atomic.LoadInt32(&e.shutdown) == 1 {
+			return
+		}
+		removed, err := cleanLogDir(e.opts.LogDir, e.opts.LogRetentionDays, time.Now())
+		if err != nil {
+			e.logWarn(MsgLogCleanFailed, "dir", e.opts.LogDir, "err", err)
+		}
+		if len(removed) > 0 {
+			e.logInfo(MsgLogCleaned, "dir", e.opts.LogDir, "removed", removed)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package xxl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestCleanLogDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2026-10-01", "2026-10-15", "2026-10-16", "2026-10-19", "other"} {
+		assert.NilError(t, os.Mkdir(filepath.Join(dir, name), 0700))
+	}
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "2026-09-01"), nil, 0600))
+
+	removed, err := cleanLogDir(dir, 3, time.Date(2026, 10, 19, 8, 0, 0, 0, time.Local))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{filepath.Join(dir, "2026-10-01"), filepath.Join(dir, "2026-10-15")}, removed)
+	entries, err := ioutil.ReadDir(dir)
+	assert.NilError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	//只删除过期的日期目录
+	assert.DeepEqual(t, []string{"2026-09-01", "2026-10-16", "2026-10-19", "other"}, names)
+
+	_, err = cleanLogDir(filepath.Join(dir, "missing"), 3, time.Now())
+	assert.Assert(t, os.IsNotExist(err))
+}
@@ -12,11 +12,11 @@ import (
 type LogHandler func(req *LogReq) *LogRes
 
 //默认返回
-func defaultLogHandler(req *LogReq) *LogRes {
+func defaultLogHandler(req *LogReq, lang Lang) *LogRes {
 	return &LogRes{Code: 200, Msg: "", Content: LogResContent{
 		FromLineNum: req.FromLineNum,
 		ToLineNum:   2,
-		LogContent:  "这是日志默认返回，说明没有设置LogHandler",
+		LogContent:  lang.Text(MsgLogDefault),
 		IsEnd:       true,
 	}}
 }
@@ -0,0 +1,31 @@
+package xxl
+
+import (
+	"context"
+	"log/slog"
+)
+
+//将slog.Logger适配为StructuredLogger
+func NewSlogLogger(l *slog.Logger) StructuredLogger {
+	return &slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (l *slogLogger) Debug(msg string, kv ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelDebug, msg, kv...)
+}
+
+func (l *slogLogger) Info(msg string, kv ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelInfo, msg, kv...)
+}
+
+func (l *slogLogger) Warn(msg string, kv ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelWarn, msg, kv...)
+}
+
+func (l *slogLogger) Error(msg string, kv ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelError, msg, kv...)
+}
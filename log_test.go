@@ -0,0 +1,106 @@
+package xxl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+//记录旧Logger的输出
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) Info(format string, a ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, "INFO "+fmt.Sprintf(format, a...))
+}
+
+func (l *recordLogger) Error(format string, a ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, "ERROR "+fmt.Sprintf(format, a...))
+}
+
+func TestFromLogger(t *testing.T) {
+	r := &recordLogger{}
+	l := FromLogger(r)
+	l.Debug("debug %d", "jobId", 1)
+	l.Info("info", "handler", "task.a", "msg", "a b", "empty", "")
+	l.Warn("warn", "odd")
+	l.Error("error", "err", fmt.Errorf("boom"))
+	assert.DeepEqual(t, []string{
+		"INFO debug %d jobId=1",
+		`INFO info handler=task.a msg="a b" empty=""`,
+		"ERROR warn odd=<missing>",
+		"ERROR error err=boom",
+	}, r.lines)
+}
+
+func TestStructuredFields(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&lockedWriter{w: &buf, mu: &mu}, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	done := make(chan struct{}, 1)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+		if r.URL.Path == "/api/callback" {
+			done <- struct{}{}
+		}
+	}))
+	defer admin.Close()
+
+	e := newExecutor(ServerAddr(admin.URL), SetStructuredLogger(l))
+	e.Init()
+	e.RegTask("task.a", func(cxt context.Context, param *RunReq) string { return "ok" })
+	w := httptest.NewRecorder()
+	e.runTask(w, httptest.NewRequest("POST", "/run", strings.NewReader(`{"jobId":3,"logId":30,"executorHandler":"task.a"}`)))
+	<-done
+	e.runTask(w, httptest.NewRequest("POST", "/run", strings.NewReader(`{"jobId":4,"logId":40,"executorHandler":"task.none"}`)))
+
+	mu.Lock()
+	defer mu.Unlock()
+	var started, missing bool
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		rec := map[string]interface{}{}
+		assert.NilError(t, json.Unmarshal([]byte(line), &rec), line)
+		switch rec["msg"] {
+		case "任务开始执行":
+			started = true
+			assert.Equal(t, "INFO", rec["level"])
+			assert.Equal(t, 3.0, rec["jobId"])
+			assert.Equal(t, 30.0, rec["logId"])
+			assert.Equal(t, "task.a", rec["handler"])
+		case "任务没有注册":
+			missing = true
+			assert.Equal(t, "ERROR", rec["level"])
+			assert.Equal(t, 4.0, rec["jobId"])
+			assert.Equal(t, "task.none", rec["handler"])
+		}
+	}
+	assert.Assert(t, started)
+	assert.Assert(t, missing)
+}
+
+type lockedWriter struct {
+	w  *bytes.Buffer
+	mu *sync.Mutex
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
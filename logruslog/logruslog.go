@@ -0,0 +1,47 @@
+//logrus日志适配
+package logruslog
+
+import (
+	"fmt"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+	"github.com/sirupsen/logrus"
+)
+
+//将logrus.FieldLogger适配为xxl.StructuredLogger
+func New(l logrus.FieldLogger) xxl.StructuredLogger {
+	return &logger{l: l}
+}
+
+type logger struct {
+	l logrus.FieldLogger
+}
+
+func (l *logger) Debug(msg string, kv ...interface{}) {
+	l.l.WithFields(fields(kv)).Debug(msg)
+}
+
+func (l *logger) Info(msg string, kv ...interface{}) {
+	l.l.WithFields(fields(kv)).Info(msg)
+}
+
+func (l *logger) Warn(msg string, kv ...interface{}) {
+	l.l.WithFields(fields(kv)).Warn(msg)
+}
+
+func (l *logger) Error(msg string, kv ...interface{}) {
+	l.l.WithFields(fields(kv)).Error(msg)
+}
+
+//key、value对转为logrus.Fields
+func fields(kv []interface{}) logrus.Fields {
+	f := make(logrus.Fields, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 >= len(kv) {
+			f[fmt.Sprint(kv[i])] = "<missing>"
+			break
+		}
+		f[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+	return f
+}
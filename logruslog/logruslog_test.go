@@ -0,0 +1,26 @@
+package logruslog
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"gotest.tools/assert"
+)
+
+func TestLogger(t *testing.T) {
+	base, hook := test.NewNullLogger()
+	base.SetLevel(logrus.DebugLevel)
+	l := New(base)
+	l.Debug("debug")
+	l.Info("info", "jobId", int64(1), "handler", "task.a")
+	l.Warn("warn", "odd")
+	l.Error("error", "logId", int64(2))
+
+	assert.Equal(t, 4, len(hook.AllEntries()))
+	info := hook.AllEntries()[1]
+	assert.Equal(t, logrus.InfoLevel, info.Level)
+	assert.DeepEqual(t, logrus.Fields{"jobId": int64(1), "handler": "task.a"}, info.Data)
+	assert.DeepEqual(t, logrus.Fields{"odd": "<missing>"}, hook.AllEntries()[2].Data)
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+}
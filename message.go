@@ -0,0 +1,203 @@
+package xxl
+
+//消息语言
+type Lang string
+
+const (
+	LangZh Lang = "zh" //中文(默认)
+	LangEn Lang = "en" //英文
+)
+
+//消息编码,不随语言变化,日志中为msgCode字段,回调和响应消息以[编码]开头,告警规则可按编码匹配
+type MsgCode string
+
+const (
+	MsgInitFailed         MsgCode = "INIT_FAILED"
+	MsgServerStarted      MsgCode = "SERVER_STARTED"
+	MsgParamsErr          MsgCode = "PARAMS_ERR"
+	MsgParamsDecodeErr    MsgCode = "PARAMS_DECODE_ERR"
+	MsgTaskParams         MsgCode = "TASK_PARAMS"
+	MsgTaskNotRegistered  MsgCode = "TASK_NOT_REGISTERED"
+	MsgTaskDuplicate      MsgCode = "TASK_DUPLICATE"
+	MsgTaskRunning        MsgCode = "TASK_RUNNING"
+	MsgTaskStarted        MsgCode = "TASK_STARTED"
+	MsgTaskNotRunning     MsgCode = "TASK_NOT_RUNNING"
+	MsgTaskInfo           MsgCode = "TASK_INFO"
+	MsgTaskPanic          MsgCode = "TASK_PANIC"
+	MsgExecutorBusy       MsgCode = "EXECUTOR_BUSY"
+	MsgTaskTimeout        MsgCode = "TASK_TIMEOUT"
+	MsgTaskAbandoned      MsgCode = "TASK_ABANDONED"
+	MsgTaskCanceled       MsgCode = "TASK_CANCELED"
+	MsgHookPanic          MsgCode = "HOOK_PANIC"
+	MsgAlertFailure       MsgCode = "ALERT_FAILURE"
+	MsgAlertConsecutive   MsgCode = "ALERT_CONSECUTIVE"
+	MsgAlertFailed        MsgCode = "ALERT_FAILED"
+	MsgAlertSuppressed    MsgCode = "ALERT_SUPPRESSED"
+	MsgHistorySaveFailed  MsgCode = "HISTORY_SAVE_FAILED"
+	MsgLogReqFailed       MsgCode = "LOG_REQ_FAILED"
+	MsgLogReq             MsgCode = "LOG_REQ"
+	MsgLogDefault         MsgCode = "LOG_DEFAULT"
+	MsgLogCleaned         MsgCode = "LOG_CLEANED"
+	MsgLogCleanFailed     MsgCode = "LOG_CLEAN_FAILED"
+	MsgRegistryParams     MsgCode = "REGISTRY_PARAMS"
+	MsgRegistryOK         MsgCode = "REGISTRY_OK"
+	MsgRegistryFailed     MsgCode = "REGISTRY_FAILED"
+	MsgRegistryRemoveOK   MsgCode = "REGISTRY_REMOVE_OK"
+	MsgRegistryRemoveFail MsgCode = "REGISTRY_REMOVE_FAILED"
+	MsgCallbackOK         MsgCode = "CALLBACK_OK"
+	MsgCallbackFailed     MsgCode = "CALLBACK_FAILED"
+	MsgHTTPPanic          MsgCode = "HTTP_PANIC"
+	MsgNotFound           MsgCode = "NOT_FOUND"
+	MsgUnauthorized       MsgCode = "UNAUTHORIZED"
+	MsgNotRegistered      MsgCode = "NOT_REGISTERED"
+	MsgShuttingDown       MsgCode = "SHUTTING_DOWN"
+	MsgJobAdd             MsgCode = "JOB_ADD"
+	MsgJobAddOK           MsgCode = "JOB_ADD_OK"
+	MsgJobAddFailed       MsgCode = "JOB_ADD_FAILED"
+	MsgJobStart           MsgCode = "JOB_START"
+	MsgJobStartOK         MsgCode = "JOB_START_OK"
+	MsgJobStartFailed     MsgCode = "JOB_START_FAILED"
+	MsgJobStopOK          MsgCode = "JOB_STOP_OK"
+	MsgJobStopFailed      MsgCode = "JOB_STOP_FAILED"
+)
+
+//消息目录
+var messages = map[Lang]map[MsgCode]string{
+	LangZh: {
+		MsgInitFailed:         "执行器初始化失败",
+		MsgServerStarted:      "执行器启动",
+		MsgParamsErr:          "参数解析错误",
+		MsgParamsDecodeErr:    "任务参数解析失败",
+		MsgTaskParams:         "任务参数",
+		MsgTaskNotRegistered:  "任务没有注册",
+		MsgTaskDuplicate:      "任务重复注册",
+		MsgTaskRunning:        "任务已经在运行了",
+		MsgTaskStarted:        "任务开始执行",
+		MsgTaskNotRunning:     "任务没有运行",
+		MsgTaskInfo:           "任务ID[%d]任务名称[%s]参数：%s",
+		MsgTaskPanic:          "任务panic",
+		MsgExecutorBusy:       "执行器繁忙",
+		MsgTaskTimeout:        "任务执行超时",
+		MsgTaskAbandoned:      "已超时放弃的任务执行结束",
+		MsgTaskCanceled:       "任务排队时被取消",
+		MsgHookPanic:          "钩子panic",
+		MsgAlertFailure:       "任务执行失败",
+		MsgAlertConsecutive:   "任务连续失败",
+		MsgAlertFailed:        "告警发送失败",
+		MsgAlertSuppressed:    "告警已忽略",
+		MsgHistorySaveFailed:  "执行记录保存失败",
+		MsgLogReqFailed:       "日志请求失败",
+		MsgLogReq:             "日志请求参数",
+		MsgLogDefault:         "这是日志默认返回，说明没有设置LogHandler",
+		MsgLogCleaned:         "过期日志已删除",
+		MsgLogCleanFailed:     "过期日志删除失败",
+		MsgRegistryParams:     "注册xxl-job参数",
+		MsgRegistryOK:         "执行器注册成功",
+		MsgRegistryFailed:     "执行器注册失败",
+		MsgRegistryRemoveOK:   "执行器摘除成功",
+		MsgRegistryRemoveFail: "执行器摘除失败",
+		MsgCallbackOK:         "任务回调成功",
+		MsgCallbackFailed:     "任务回调失败",
+		MsgHTTPPanic:          "请求panic",
+		MsgNotFound:           "路径不存在",
+		MsgUnauthorized:       "未认证",
+		MsgNotRegistered:      "执行器未注册",
+		MsgShuttingDown:       "执行器正在停止",
+		MsgJobAdd:             "增加任务",
+		MsgJobAddOK:           "任务增加成功",
+		MsgJobAddFailed:       "任务增加失败",
+		MsgJobStart:           "启动任务",
+		MsgJobStartOK:         "任务启动成功",
+		MsgJobStartFailed:     "任务启动失败",
+		MsgJobStopOK:          "任务停止成功",
+		MsgJobStopFailed:      "任务停止失败",
+	},
+	LangEn: {
+		MsgInitFailed:         "executor init failed",
+		MsgServerStarted:      "executor server started",
+		MsgParamsErr:          "invalid params",
+		MsgParamsDecodeErr:    "invalid task params",
+		MsgTaskParams:         "task params",
+		MsgTaskNotRegistered:  "task not registered",
+		MsgTaskDuplicate:      "task registered twice",
+		MsgTaskRunning:        "task is already running",
+		MsgTaskStarted:        "task started",
+		MsgTaskNotRunning:     "task is not running",
+		MsgTaskInfo:           "task id[%d] name[%s] params: %s",
+		MsgTaskPanic:          "task panic",
+		MsgExecutorBusy:       "executor busy",
+		MsgTaskTimeout:        "task execute timeout",
+		MsgTaskAbandoned:      "abandoned task finished after timeout",
+		MsgTaskCanceled:       "task canceled while queued",
+		MsgHookPanic:          "hook panic",
+		MsgAlertFailure:       "task failed",
+		MsgAlertConsecutive:   "task failed consecutively",
+		MsgAlertFailed:        "alert send failed",
+		MsgAlertSuppressed:    "alert suppressed",
+		MsgHistorySaveFailed:  "run history save failed",
+		MsgLogReqFailed:       "log request failed",
+		MsgLogReq:             "log request",
+		MsgLogDefault:         "default log response, no LogHandler is set",
+		MsgLogCleaned:         "expired logs removed",
+		MsgLogCleanFailed:     "expired logs remove failed",
+		MsgRegistryParams:     "registry params",
+		MsgRegistryOK:         "executor registered",
+		MsgRegistryFailed:     "executor registry failed",
+		MsgRegistryRemoveOK:   "executor registry removed",
+		MsgRegistryRemoveFail: "executor registry remove failed",
+		MsgCallbackOK:         "task callback succeeded",
+		MsgCallbackFailed:     "task callback failed",
+		MsgHTTPPanic:          "http handler panic",
+		MsgNotFound:           "not found",
+		MsgUnauthorized:       "unauthorized",
+		MsgNotRegistered:      "executor not registered",
+		MsgShuttingDown:       "executor shutting down",
+		MsgJobAdd:             "add job",
+		MsgJobAddOK:           "job added",
+		MsgJobAddFailed:       "add job failed",
+		MsgJobStart:           "start job",
+		MsgJobStartOK:         "job started",
+		MsgJobStartFailed:     "start job failed",
+		MsgJobStopOK:          "job stopped",
+		MsgJobStopFailed:      "stop job failed",
+	},
+}
+
+//消息文本,未知语言使用中文
+func (l Lang) Text(code MsgCode) string {
+	if m, ok := messages[l]; ok {
+		if s, ok := m[code]; ok {
+			return s
+		}
+	}
+	if s, ok := messages[LangZh][code]; ok {
+		return s
+	}
+	return string(code)
+}
+
+//回调和响应消息: [编码] 文本
+func (l Lang) Msg(code MsgCode) string {
+	return "[" + string(code) + "] " + l.Text(code)
+}
+
+//回调和响应消息,附加详情: [编码] 文本: 详情
+func (l Lang) Msgf(code MsgCode, detail string) string {
+	return l.Msg(code) + ": " + detail
+}
+
+func (e *executor) logDebug(code MsgCode, kv ...interface{}) {
+	e.log.Debug(e.opts.lang.Text(code), append([]interface{}{"msgCode", code}, kv...)...)
+}
+
+func (e *executor) logInfo(code MsgCode, kv ...interface{}) {
+	e.log.Info(e.opts.lang.Text(code), append([]interface{}{"msgCode", code}, kv...)...)
+}
+
+func (e *executor) logWarn(code MsgCode, kv ...interface{}) {
+	e.log.Warn(e.opts.lang.Text(code), append([]interface{}{"msgCode", code}, kv...)...)
+}
+
+func (e *executor) logError(code MsgCode, kv ...interface{}) {
+	e.log.Error(e.opts.lang.Text(code), append([]interface{}{"msgCode", code}, kv...)...)
+}
@@ -0,0 +1,49 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestMessageCatalog(t *testing.T) {
+	assert.Equal(t, len(messages[LangZh]), len(messages[LangEn]))
+	for code := range messages[LangZh] {
+		_, ok := messages[LangEn][code]
+		assert.Assert(t, ok, code)
+	}
+	assert.Equal(t, "[TASK_NOT_REGISTERED] task not registered", LangEn.Msg(MsgTaskNotRegistered))
+	assert.Equal(t, "[TASK_PANIC] 任务panic: boom", LangZh.Msgf(MsgTaskPanic, "boom"))
+	assert.Equal(t, "任务没有注册", Lang("fr").Text(MsgTaskNotRegistered))
+	assert.Equal(t, "UNKNOWN", LangEn.Text("UNKNOWN"))
+
+	task := &Task{Id: 1, Name: "task.a", Param: &RunReq{ExecutorParams: "x=1"}}
+	assert.Equal(t, "任务ID[1]任务名称[task.a]参数：x=1", task.Info())
+	task.lang = LangEn
+	assert.Equal(t, "task id[1] name[task.a] params: x=1", task.Info())
+}
+
+func TestLanguageResponses(t *testing.T) {
+	r := &recordLogger{}
+	e := newExecutor(Language(LangEn), SetLogger(r))
+	e.Init()
+	w := httptest.NewRecorder()
+	e.runTask(w, httptest.NewRequest("POST", "/run", strings.NewReader(`{"jobId":1,"logId":2,"executorHandler":"task.none"}`)))
+
+	var resp call
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "[TASK_NOT_REGISTERED] task not registered", resp[0].ExecuteResult.Msg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found bool
+	for _, line := range r.lines {
+		if strings.HasPrefix(line, "ERROR task not registered msgCode=TASK_NOT_REGISTERED jobId=1 logId=2 handler=task.none") {
+			found = true
+		}
+	}
+	assert.Assert(t, found, strings.Join(r.lines, "\n"))
+}
@@ -0,0 +1,205 @@
+package xxl
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//指标命名空间
+const metricsNamespace = "xxl_job_executor"
+
+//默认指标路径
+var DefaultMetricsPath = "/metrics"
+
+//拒绝调度原因
+const (
+	rejectNotRegistered = "not_registered" //任务未注册
+	rejectBlocked       = "blocked"        //阻塞策略拒绝
+	rejectBadParams     = "bad_params"     //参数错误
+	rejectBusy          = "busy"           //超过并发限制
+)
+
+//未匹配到注册任务时的handler标签,避免调度中心传入的任意名称产生无限的标签
+const unknownHandler = "unknown"
+
+//执行器指标, 未开启时为nil, 所有方法均可在nil上调用;handler标签为注册的pattern
+type metrics struct {
+	registry prometheus.Registerer
+	gatherer prometheus.Gatherer
+
+	triggers         *prometheus.CounterVec
+	rejected         *prometheus.CounterVec
+	duration         *prometheus.HistogramVec
+	results          *prometheus.CounterVec
+	panics           *prometheus.CounterVec
+	kills            *prometheus.CounterVec
+	timeouts         *prometheus.CounterVec
+	callbackFailures prometheus.Counter
+	registryFailures prometheus.Counter
+}
+
+//创建并注册指标
+func newMetrics(reg prometheus.Registerer, gatherer prometheus.Gatherer, running func() int, l *limiter) (*metrics, error) {
+	if gatherer == nil {
+		g, ok := reg.(prometheus.Gatherer)
+		if !ok {
+			return nil, errors.New("xxl metrics: gatherer is required when registerer is not a prometheus.Gatherer")
+		}
+		gatherer = g
+	}
+	m := &metrics{
+		registry: reg,
+		gatherer: gatherer,
+		triggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "triggers_total",
+			Help:      "Triggers received from the admin, by handler.",
+		}, []string{"handler"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "triggers_rejected_total",
+			Help:      "Triggers rejected before running, by handler and reason.",
+		}, []string{"handler", "reason"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "run_duration_seconds",
+			Help:      "Task run duration in seconds, by handler.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+		}, []string{"handler"}),
+		results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "results_total",
+			Help:      "Task results reported to the admin, by handler and code.",
+		}, []string{"handler", "code"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "panics_total",
+			Help:      "Task panics, by handler.",
+		}, []string{"handler"}),
+		kills: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "kills_total",
+			Help:      "Tasks killed by the admin, by handler.",
+		}, []string{"handler"}),
+		timeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "timeouts_total",
+			Help:      "Tasks that exceeded their timeout, by handler.",
+		}, []string{"handler"}),
+		callbackFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "callback_failures_total",
+			Help:      "Failed result callbacks to the admin.",
+		}),
+		registryFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "registry_failures_total",
+			Help:      "Failed registry heartbeats to the admin.",
+		}),
+	}
+	collectors := []prometheus.Collector{
+		m.triggers,
+		m.rejected,
+		m.duration,
+		m.results,
+		m.panics,
+		m.kills,
+		m.timeouts,
+		m.callbackFailures,
+		m.registryFailures,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "running_tasks",
+			Help:      "Tasks currently in the run list.",
+		}, func() float64 { return float64(running()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "concurrency_running",
+			Help:      "Tasks holding a concurrency slot.",
+		}, func() float64 { return float64(atomic.LoadInt64(&l.running)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "concurrency_queued",
+			Help:      "Tasks waiting for a concurrency slot.",
+		}, func() float64 { return float64(atomic.LoadInt64(&l.queued)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "abandoned_tasks",
+			Help:      "Timed out tasks whose handler has not returned yet.",
+		}, func() float64 { return float64(atomic.LoadInt64(&l.abandoned)) }),
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+//指标http handler
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *metrics) trigger(handler string) {
+	if m == nil {
+		return
+	}
+	m.triggers.WithLabelValues(handler).Inc()
+}
+
+func (m *metrics) reject(handler, reason string) {
+	if m == nil {
+		return
+	}
+	m.rejected.WithLabelValues(handler, reason).Inc()
+}
+
+func (m *metrics) finish(handler string, code int64, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.duration.WithLabelValues(handler).Observe(d.Seconds())
+	m.results.WithLabelValues(handler, strconv.FormatInt(code, 10)).Inc()
+}
+
+func (m *metrics) panic(handler string) {
+	if m == nil {
+		return
+	}
+	m.panics.WithLabelValues(handler).Inc()
+}
+
+func (m *metrics) kill(handler string) {
+	if m == nil {
+		return
+	}
+	m.kills.WithLabelValues(handler).Inc()
+}
+
+func (m *metrics) timeout(handler string) {
+	if m == nil {
+		return
+	}
+	m.timeouts.WithLabelValues(handler).Inc()
+}
+
+func (m *metrics) callbackFailure() {
+	if m == nil {
+		return
+	}
+	m.callbackFailures.Inc()
+}
+
+func (m *metrics) registryFailure() {
+	if m == nil {
+		return
+	}
+	m.registryFailures.Inc()
+}
@@ -0,0 +1,88 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	done := make(chan struct{}, 4)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+		if r.URL.Path == "/api/callback" {
+			done <- struct{}{}
+		}
+	}))
+	defer admin.Close()
+
+	reg := prometheus.NewRegistry()
+	e := newExecutor(ServerAddr(admin.URL), Metrics(reg, nil))
+	e.Init()
+	e.RegTask("task.ok", func(cxt context.Context, param *RunReq) string { return "ok" })
+	e.RegTask("task.panic", func(cxt context.Context, param *RunReq) string { panic("boom") })
+	e.RegTask("report.*", func(cxt context.Context, param *RunReq) string { return "ok" })
+
+	trigger := func(body string) string {
+		w := httptest.NewRecorder()
+		e.runTask(w, httptest.NewRequest("POST", "/run", strings.NewReader(body)))
+		return w.Body.String()
+	}
+	trigger(`{"jobId":1,"executorHandler":"task.ok","logId":1}`)
+	<-done
+	trigger(`{"jobId":2,"executorHandler":"task.panic","logId":2}`)
+	<-done
+	trigger(`{"jobId":3,"executorHandler":"task.none","logId":3}`)
+	trigger(`{"jobId":4,"executorHandler":"task.other","logId":4}`)
+	trigger(`{"jobId":5,"executorHandler":"report.daily","logId":5}`)
+	<-done
+	trigger(`not json`)
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.metrics.triggers.WithLabelValues("task.ok")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.metrics.results.WithLabelValues("task.ok", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.metrics.results.WithLabelValues("task.panic", "500")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.metrics.panics.WithLabelValues("task.panic")))
+	//未注册的名称与匹配到pattern的名称不产生新的标签
+	assert.Equal(t, 2.0, testutil.ToFloat64(e.metrics.rejected.WithLabelValues(unknownHandler, rejectNotRegistered)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.metrics.rejected.WithLabelValues(unknownHandler, rejectBadParams)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(e.metrics.results.WithLabelValues("report.*", "200")))
+	assert.Equal(t, 4, testutil.CollectAndCount(e.metrics.triggers))
+	assert.Equal(t, 0.0, testutil.ToFloat64(e.metrics.callbackFailures))
+
+	w := httptest.NewRecorder()
+	e.metrics.handler().ServeHTTP(w, httptest.NewRequest("GET", DefaultMetricsPath, nil))
+	assert.Assert(t, strings.Contains(w.Body.String(), "xxl_job_executor_running_tasks 0"))
+}
+
+func TestMetricsRegisterer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	wrapped := prometheus.WrapRegistererWith(prometheus.Labels{"app": "demo"}, reg)
+
+	e := newExecutor(ServerAddr("http://127.0.0.1:1"), Metrics(wrapped, nil))
+	e.Init()
+	assert.ErrorContains(t, e.initErr, "gatherer is required")
+
+	e = newExecutor(ServerAddr("http://127.0.0.1:1"), Metrics(wrapped, reg))
+	e.Init()
+	assert.NilError(t, e.initErr)
+	w := httptest.NewRecorder()
+	e.handler().ServeHTTP(w, httptest.NewRequest("GET", DefaultMetricsPath, nil))
+	assert.Assert(t, strings.Contains(w.Body.String(), `xxl_job_executor_running_tasks{app="demo"} 0`))
+
+	//重复注册返回错误
+	reg = prometheus.NewRegistry()
+	e = newExecutor(ServerAddr("http://127.0.0.1:1"), Metrics(reg, nil))
+	e.Init()
+	assert.NilError(t, e.initErr)
+	e = newExecutor(ServerAddr("http://127.0.0.1:1"), Metrics(reg, nil))
+	e.Init()
+	assert.ErrorContains(t, e.initErr, "duplicate")
+}
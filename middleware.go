@@ -0,0 +1,37 @@
+package xxl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+//捕获handler的panic,返回500,避免进程退出
+func (e *executor) recoverHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				e.logError(MsgHTTPPanic, "path", request.URL.Path, "err", err, "stack", string(debug.Stack()))
+				writeRes(writer, http.StatusInternalServerError, e.opts.lang.Msgf(MsgHTTPPanic, fmt.Sprint(err)))
+			}
+		}()
+		next.ServeHTTP(writer, request)
+	})
+}
+
+//未知路径
+func (e *executor) notFound(writer http.ResponseWriter, request *http.Request) {
+	writeRes(writer, http.StatusNotFound, e.opts.lang.Msgf(MsgNotFound, request.URL.Path))
+}
+
+//以通用响应格式返回
+func writeRes(writer http.ResponseWriter, code int, msg string) {
+	str, _ := json.Marshal(&res{Code: int64(code), Msg: msg})
+	writer.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	writer.WriteHeader(code)
+	_, _ = writer.Write(str)
+}
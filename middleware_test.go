@@ -0,0 +1,42 @@
+package xxl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHandlerRoutes(t *testing.T) {
+	serve := func(e *executor, path, body string) (int, *res) {
+		w := httptest.NewRecorder()
+		e.handler().ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		r := &res{}
+		_ = json.Unmarshal(w.Body.Bytes(), r)
+		return w.Code, r
+	}
+
+	e := newExecutor()
+	e.Init()
+	code, r := serve(e, "/unknown", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, int64(404), r.Code)
+	for _, path := range []string{"/ppp", "/pan", "/debug/panic", "/debug/echo"} {
+		code, _ = serve(e, path, "")
+		assert.Equal(t, http.StatusNotFound, code, path)
+	}
+
+	//调试模式
+	e = newExecutor(Debug(true))
+	e.Init()
+	code, r = serve(e, "/debug/panic", "")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, int64(500), r.Code)
+	w := httptest.NewRecorder()
+	e.handler().ServeHTTP(w, httptest.NewRequest("POST", "/debug/echo", strings.NewReader(`{"jobId":7}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Assert(t, strings.Contains(w.Body.String(), `"jobId":7`))
+}
@@ -1,27 +1,79 @@
 package xxl
 
 import (
-	"github.com/go-basic/ipv4"
+	"net"
+	"net/http"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Options struct {
-	ServerAddr   string        `json:"server_addr"`   //调度中心地址
-	AccessToken  string        `json:"access_token"`  //请求令牌
-	Timeout      time.Duration `json:"timeout"`       //接口超时时间
-	ExecutorIp   string        `json:"executor_ip"`   //本地(执行器)IP(可自行获取)
-	ExecutorPort string        `json:"executor_port"` //本地(执行器)端口
-	RegistryKey  string        `json:"registry_key"`  //执行器名称
-	LogDir       string        `json:"log_dir"`       //日志目录
+	ServerAddr       string        `json:"server_addr"`        //调度中心地址,多个时以逗号分隔
+	AccessToken      string        `json:"access_token"`       //请求令牌
+	Timeout          time.Duration `json:"timeout"`            //接口超时时间
+	ExecutorIp       string        `json:"executor_ip"`        //本地(执行器)IP(可自行获取)
+	ExecutorPort     string        `json:"executor_port"`      //本地(执行器)端口
+	AdvertiseAddr    string        `json:"advertise_addr"`     //注册到调度中心的地址(可带路径前缀),为空时使用ExecutorIp:ExecutorPort
+	RegistryKey      string        `json:"registry_key"`       //执行器名称
+	LogDir           string        `json:"log_dir"`            //日志目录
+	LogRetentionDays int           `json:"log_retention_days"` //LogDir下按日期命名的日志目录保存天数,小于3时不清理
+
+	ipDiscovery IPDiscovery //未设置ExecutorIp时自动获取IP的配置
+
+	l StructuredLogger //日志处理
+
+	registry    prometheus.Registerer //指标注册器,为nil时不开启指标
+	gatherer    prometheus.Gatherer   //指标采集,为nil时使用registry
+	metricsPath string                //指标路径
+
+	tracerProvider trace.TracerProvider //链路追踪,为nil时使用otel全局设置
+
+	debug bool //调试模式,开启后增加/debug/路由
+
+	console         bool   //控制台,开启后增加/console/路由
+	consoleUser     string //控制台basic auth用户名,为空时使用AccessToken认证
+	consolePassword string //控制台basic auth密码
+
+	lang Lang //日志与响应消息语言
 
-	l Logger //日志处理
+	maxConcurrency     int            //全局并发限制,0为不限制
+	handlerConcurrency map[string]int //任务并发限制
+	limitPolicy        LimitPolicy    //超过并发限制时的处理策略
+	queueSize          int            //排队长度
+
+	duplicatePolicy DuplicatePolicy //重复注册同名任务时的处理策略
+
+	hooks []Hooks //任务生命周期钩子
+
+	historySize    int            //保留的执行记录数
+	historyBackend HistoryBackend //执行记录持久化,为nil时只保留在内存
+
+	alerter     Alerter     //任务失败告警,为nil时不开启
+	alertPolicy AlertPolicy //告警策略
+
+	listener net.Listener //自定义监听,为nil时监听ExecutorIp:ExecutorPort
+	server   *http.Server //自定义服务器
+
+	tlsCertFile     string //执行器服务端证书
+	tlsKeyFile      string //执行器服务端私钥
+	tlsClientCAFile string //校验调度中心客户端证书的CA,为空时不校验
+	adminCAFile     string //校验调度中心证书的CA,为空时使用系统CA
+	adminCertFile   string //请求调度中心的客户端证书
+	adminKeyFile    string //请求调度中心的客户端私钥
+
+	httpClient       *http.Client             //请求调度中心的客户端,为nil时新建
+	transport        http.RoundTripper        //请求调度中心的Transport,为nil时使用httpClient的Transport或http.DefaultTransport
+	clientMiddleware []RoundTripperMiddleware //请求调度中心的RoundTripper中间件
 }
 
 func newOptions(opts ...Option) Options {
 	opt := Options{
-		ExecutorIp:   ipv4.LocalIP(),
 		ExecutorPort: DefaultExecutorPort,
 		RegistryKey:  DefaultRegistryKey,
+		metricsPath:  DefaultMetricsPath,
+		lang:         LangZh,
 	}
 
 	for _, o := range opts {
@@ -29,7 +81,7 @@ func newOptions(opts ...Option) Options {
 	}
 
 	if opt.l == nil {
-		opt.l = &logger{}
+		opt.l = FromLogger(&logger{})
 	}
 
 	return opt
@@ -42,7 +94,7 @@ var (
 	DefaultRegistryKey  = "golang-jobs"
 )
 
-// 设置调度中心地址
+// 设置调度中心地址,多个时以逗号分隔
 func ServerAddr(addr string) Option {
 	return func(o *Options) {
 		o.ServerAddr = addr
@@ -79,7 +131,216 @@ func RegistryKey(registryKey string) Option {
 
 // 设置日志处理器
 func SetLogger(l Logger) Option {
+	return func(o *Options) {
+		o.l = FromLogger(l)
+	}
+}
+
+// 设置结构化日志处理器,可使用NewSlogLogger、zaplog.New、logruslog.New适配
+func SetStructuredLogger(l StructuredLogger) Option {
 	return func(o *Options) {
 		o.l = l
 	}
 }
+
+// 开启prometheus指标,注册到reg,在执行器路由上提供gatherer中的指标;
+// gatherer为nil时使用reg(reg需为*prometheus.Registry),如:
+//	xxl.Metrics(prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
+func Metrics(reg prometheus.Registerer, gatherer prometheus.Gatherer) Option {
+	return func(o *Options) {
+		o.registry = reg
+		o.gatherer = gatherer
+	}
+}
+
+// 设置指标路径,默认/metrics
+func MetricsPath(path string) Option {
+	return func(o *Options) {
+		o.metricsPath = path
+	}
+}
+
+// 设置链路追踪TracerProvider
+func TracerProvider(tp trace.TracerProvider) Option {
+	return func(o *Options) {
+		o.tracerProvider = tp
+	}
+}
+
+// 开启调试模式,增加/debug/echo、/debug/panic、/debug/pprof/路由,默认关闭,不要在生产环境开启
+func Debug(enable bool) Option {
+	return func(o *Options) {
+		o.debug = enable
+	}
+}
+
+// 使用已有的监听,如测试或systemd socket activation;未设置AdvertiseAddr时注册监听的地址
+func Listener(l net.Listener) Option {
+	return func(o *Options) {
+		o.listener = l
+	}
+}
+
+// 使用自定义http.Server(超时、TLS、ErrorLog等),Addr为空时监听ExecutorIp:ExecutorPort,Handler为空时使用执行器路由;
+// Run使用server的副本,不修改传入的server
+func HTTPServer(server *http.Server) Option {
+	return func(o *Options) {
+		o.server = server
+	}
+}
+
+// 执行器服务端开启TLS,注册地址使用https;同时设置HTTPServer的TLSConfig时以此为准
+func TLS(certFile, keyFile string) Option {
+	return func(o *Options) {
+		o.tlsCertFile = certFile
+		o.tlsKeyFile = keyFile
+	}
+}
+
+// 执行器服务端校验客户端证书(mTLS),需同时设置TLS或HTTPServer的TLSConfig,都未设置时Init返回错误
+func TLSClientCA(caFile string) Option {
+	return func(o *Options) {
+		o.tlsClientCAFile = caFile
+	}
+}
+
+// 请求调度中心时使用的CA证书
+func AdminCA(caFile string) Option {
+	return func(o *Options) {
+		o.adminCAFile = caFile
+	}
+}
+
+// 请求调度中心时使用的客户端证书(mTLS)
+func AdminClientCert(certFile, keyFile string) Option {
+	return func(o *Options) {
+		o.adminCertFile = certFile
+		o.adminKeyFile = keyFile
+	}
+}
+
+// 设置注册到调度中心的地址(NAT、Docker、Kubernetes等调度中心访问地址与监听地址不同时),需为带scheme的完整URL,可带路径前缀
+// 未设置ExecutorIp时监听全部网卡
+func AdvertiseAddr(addr string) Option {
+	return func(o *Options) {
+		o.AdvertiseAddr = addr
+	}
+}
+
+// 设置自动获取执行器IP的偏好(网卡、网段、IPv6等),未设置ExecutorIp时生效
+func ExecutorIPDiscovery(d IPDiscovery) Option {
+	return func(o *Options) {
+		o.ipDiscovery = d
+	}
+}
+
+// 设置日志与回调消息语言,默认中文,消息编码不随语言变化
+func Language(lang Lang) Option {
+	return func(o *Options) {
+		o.lang = lang
+	}
+}
+
+// 设置全局最大并发执行任务数,0为不限制
+func MaxConcurrency(n int) Option {
+	return func(o *Options) {
+		o.maxConcurrency = n
+	}
+}
+
+// 设置单个任务的最大并发数,handler为RegTask注册的pattern,如report.*对所有匹配的任务共同限制
+func HandlerMaxConcurrency(handler string, n int) Option {
+	return func(o *Options) {
+		if o.handlerConcurrency == nil {
+			o.handlerConcurrency = make(map[string]int)
+		}
+		o.handlerConcurrency[handler] = n
+	}
+}
+
+// 设置超过并发限制时的处理策略,默认LimitReject;LimitQueue时最多排队queueSize个
+func ConcurrencyPolicy(policy LimitPolicy, queueSize int) Option {
+	return func(o *Options) {
+		o.limitPolicy = policy
+		o.queueSize = queueSize
+	}
+}
+
+// 设置重复注册同名任务时的处理策略,默认DuplicateReplace
+func OnDuplicate(policy DuplicatePolicy) Option {
+	return func(o *Options) {
+		o.duplicatePolicy = policy
+	}
+}
+
+// 设置所有任务的生命周期钩子,可多次设置,按顺序调用
+func WithHooks(h Hooks) Option {
+	return func(o *Options) {
+		o.hooks = append(o.hooks, h)
+	}
+}
+
+// 开启任务失败告警,在失败、panic、超时或连续失败时调用alerter
+func Alerting(alerter Alerter, policy AlertPolicy) Option {
+	return func(o *Options) {
+		o.alerter = alerter
+		o.alertPolicy = policy
+	}
+}
+
+// 设置内存中保留的执行记录数,默认DefaultHistorySize
+func HistorySize(n int) Option {
+	return func(o *Options) {
+		o.historySize = n
+	}
+}
+
+// 设置执行记录持久化
+func HistoryStore(b HistoryBackend) Option {
+	return func(o *Options) {
+		o.historyBackend = b
+	}
+}
+
+// 开启控制台/console/,默认关闭;需设置ConsoleBasicAuth或AccessToken,否则拒绝访问
+func Console(enable bool) Option {
+	return func(o *Options) {
+		o.console = enable
+	}
+}
+
+// 设置控制台basic auth
+func ConsoleBasicAuth(user, password string) Option {
+	return func(o *Options) {
+		o.consoleUser = user
+		o.consolePassword = password
+	}
+}
+
+// 请求调度中心的超时时间
+func AdminTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// 自定义请求调度中心的客户端,不会修改传入的client
+func HTTPClient(client *http.Client) Option {
+	return func(o *Options) {
+		o.httpClient = client
+	}
+}
+
+// 自定义请求调度中心的Transport,如代理、连接池,优先于HTTPClient的Transport
+func Transport(transport http.RoundTripper) Option {
+	return func(o *Options) {
+		o.transport = transport
+	}
+}
+
+// 请求调度中心的RoundTripper中间件,如认证、签名,按顺序由外到内执行
+func ClientMiddleware(mw ...RoundTripperMiddleware) Option {
+	return func(o *Options) {
+		o.clientMiddleware = append(o.clientMiddleware, mw...)
+	}
+}
@@ -0,0 +1,100 @@
+package xxl
+
+import (
+	"context"
+	"strings"
+)
+
+/**
+任务名称匹配,与http.ServeMux类似,RegTask的pattern支持:
+	task.test         精确匹配
+	sync/{tenant}     按/分段匹配,{tenant}匹配任意一段,值通过TaskVar(cxt, "tenant")获取
+	report.*          前缀匹配,*匹配的部分通过TaskVar(cxt, "*")获取
+	*                 兜底,匹配所有未注册的任务名称
+多个pattern都能匹配时,精确匹配优先,其次为分段匹配(固定段多的优先),最后为前缀匹配(前缀长的优先)
+*/
+
+type taskVarsKey struct{}
+
+//调度匹配到的pattern与参数
+type taskVars struct {
+	pattern string
+	vars    map[string]string
+}
+
+//任务名称匹配参数,如sync/{tenant}中的tenant,前缀匹配时为"*"
+func TaskVar(cxt context.Context, name string) string {
+	return TaskVars(cxt)[name]
+}
+
+//全部匹配参数,精确匹配时为nil
+func TaskVars(cxt context.Context) map[string]string {
+	if v, ok := cxt.Value(taskVarsKey{}).(*taskVars); ok {
+		return v.vars
+	}
+	return nil
+}
+
+//匹配到的pattern
+func TaskPattern(cxt context.Context) string {
+	if v, ok := cxt.Value(taskVarsKey{}).(*taskVars); ok {
+		return v.pattern
+	}
+	return ""
+}
+
+//按名称查找任务,未匹配时返回nil
+func (e *executor) matchTask(name string) (*Task, *taskVars) {
+	if t := e.regList.Get(name); t != nil {
+		return t, &taskVars{pattern: name}
+	}
+	var (
+		best      *Task
+		bestVars  *taskVars
+		bestScore = -1
+	)
+	for _, pattern := range e.regList.Keys() {
+		vars, score, ok := matchPattern(pattern, name)
+		if !ok || score <= bestScore {
+			continue
+		}
+		if t := e.regList.Get(pattern); t != nil {
+			best, bestVars, bestScore = t, &taskVars{pattern: pattern, vars: vars}, score
+		}
+	}
+	return best, bestVars
+}
+
+//匹配pattern,score越大优先级越高;分段匹配的score总是大于前缀匹配
+func matchPattern(pattern, name string) (vars map[string]string, score int, ok bool) {
+	if strings.Contains(pattern, "{") {
+		pp, np := strings.Split(pattern, "/"), strings.Split(name, "/")
+		if len(pp) != len(np) {
+			return nil, 0, false
+		}
+		vars = make(map[string]string)
+		literal := 0
+		for i, seg := range pp {
+			if len(seg) > 2 && seg[0] == '{' && seg[len(seg)-1] == '}' {
+				if np[i] == "" {
+					return nil, 0, false
+				}
+				vars[seg[1:len(seg)-1]] = np[i]
+				continue
+			}
+			if seg != np[i] {
+				return nil, 0, false
+			}
+			literal++
+		}
+		return vars, 1<<20 + literal, true
+	}
+	if strings.HasSuffix(pattern, "*") {
+		prefix := strings.TrimSuffix(pattern, "*")
+		if !strings.HasPrefix(name, prefix) {
+			return nil, 0, false
+		}
+		return map[string]string{"*": name[len(prefix):]}, len(prefix), true
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,74 @@
+package xxl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestMatchTask(t *testing.T) {
+	e := newExecutor()
+	e.Init()
+	for _, p := range []string{"task.test", "report.*", "report.daily.*", "sync/{tenant}", "sync/{tenant}/{table}", "sync/acme/{table}", "*"} {
+		e.RegTask(p, noopTask(p))
+	}
+	cases := []struct {
+		name    string
+		pattern string
+		vars    map[string]string
+	}{
+		{"task.test", "task.test", nil},
+		{"report.weekly", "report.*", map[string]string{"*": "weekly"}},
+		{"report.daily.orders", "report.daily.*", map[string]string{"*": "orders"}},
+		{"sync/foo", "sync/{tenant}", map[string]string{"tenant": "foo"}},
+		{"sync/foo/users", "sync/{tenant}/{table}", map[string]string{"tenant": "foo", "table": "users"}},
+		{"sync/acme/users", "sync/acme/{table}", map[string]string{"table": "users"}},
+		{"sync/", "*", map[string]string{"*": "sync/"}},
+		{"other", "*", map[string]string{"*": "other"}},
+	}
+	for _, c := range cases {
+		task, vars := e.matchTask(c.name)
+		assert.Assert(t, task != nil, c.name)
+		assert.Equal(t, c.pattern, vars.pattern, c.name)
+		assert.DeepEqual(t, c.vars, vars.vars)
+		//查找与调度使用相同的匹配
+		info, ok := e.LookupHandler(c.name)
+		assert.Assert(t, ok, c.name)
+		assert.Equal(t, c.name, info.Name)
+		assert.Equal(t, c.pattern, info.Pattern, c.name)
+	}
+
+	e.Unregister("*")
+	task, _ := e.matchTask("other")
+	assert.Assert(t, task == nil)
+	_, ok := e.LookupHandler("other")
+	assert.Assert(t, !ok)
+}
+
+func TestPatternRun(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL))
+	e.Init()
+	e.RegTask("sync/{tenant}", func(cxt context.Context, param *RunReq) string {
+		return TaskPattern(cxt) + " " + TaskVar(cxt, "tenant")
+	})
+	e.RegTask("*", func(cxt context.Context, param *RunReq) string {
+		return "fallback " + TaskVar(cxt, "*")
+	})
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "sync/acme"})
+	assert.Equal(t, "sync/{tenant} acme", (<-callbacks).ExecuteResult.Msg)
+	trigger(e, &RunReq{JobID: 2, LogID: 2, ExecutorHandler: "unknown.job"})
+	assert.Equal(t, "fallback unknown.job", (<-callbacks).ExecuteResult.Msg)
+}
+
+func TestPatternNotRegistered(t *testing.T) {
+	e := newExecutor(Language(LangEn))
+	e.Init()
+	e.RegTask("report.*", noopTask("report"))
+	r := trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "sync.orders"})
+	assert.Equal(t, int64(500), r.ExecuteResult.Code)
+	assert.Assert(t, strings.HasPrefix(r.ExecuteResult.Msg.(string), "[TASK_NOT_REGISTERED]"))
+}
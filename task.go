@@ -4,41 +4,179 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //任务执行函数
 type TaskFunc func(cxt context.Context, param *RunReq) string
 
+//任务执行结果,Code为0时按200处理
+type Result struct {
+	Code int64
+	Msg  string
+}
+
+//任务注册选项
+type TaskOption func(t *Task)
+
+//任务默认超时时间,调度参数executorTimeout为0时使用
+func TaskTimeout(d time.Duration) TaskOption {
+	return func(t *Task) {
+		t.timeout = d
+	}
+}
+
+//任务最大超时时间,调度参数executorTimeout或默认超时超过时使用此值
+func TaskMaxTimeout(d time.Duration) TaskOption {
+	return func(t *Task) {
+		t.maxTimeout = d
+	}
+}
+
+//任务描述
+func TaskDescription(desc string) TaskOption {
+	return func(t *Task) {
+		t.desc = desc
+	}
+}
+
+//任务负责人
+func TaskOwner(owner string) TaskOption {
+	return func(t *Task) {
+		t.owner = owner
+	}
+}
+
+//任务生命周期钩子,在执行器钩子之后调用
+func TaskHooks(h Hooks) TaskOption {
+	return func(t *Task) {
+		t.hooks = append(t.hooks, h)
+	}
+}
+
 //任务
 type Task struct {
 	Id        int64
 	Name      string
 	Ext       context.Context
 	Param     *RunReq
-	fn        TaskFunc
+	fn        func(cxt context.Context, param *RunReq) Result
 	Cancel    context.CancelFunc
 	StartTime int64
 	EndTime   int64
 	//日志
-	log Logger
+	log StructuredLogger
+	//消息语言
+	lang Lang
+	//指标,以匹配到的注册pattern为handler标签
+	metrics *metrics
+	pattern string
+	//链路追踪
+	tracer trace.Tracer
+	//默认超时与最大超时
+	timeout    time.Duration
+	maxTimeout time.Duration
+	//描述与负责人
+	desc  string
+	owner string
+	//生命周期钩子
+	hooks []Hooks
+	//超时后不再等待任务函数返回,1为是;abandonedRuns为超时后仍在运行的任务数
+	abandoned     int32
+	abandonedRuns *int64
+	//被终止或覆盖,1为是
+	killed int32
+	//任务函数panic,1为是
+	panicked int32
+}
+
+//任务函数执行结果
+type taskResult struct {
+	code int64
+	msg  string
 }
 
-//运行任务
+//本次调度的超时时间,0为不限制
+func (t *Task) timeoutFor(param *RunReq) time.Duration {
+	d := t.timeout
+	if param.ExecutorTimeout > 0 {
+		d = time.Duration(param.ExecutorTimeout) * time.Second
+	}
+	if t.maxTimeout > 0 && (d <= 0 || d > t.maxTimeout) {
+		d = t.maxTimeout
+	}
+	return d
+}
+
+//运行任务, 超时后立即回调502, 任务函数仍在运行时标记为已放弃;
+//任务函数返回后Run才返回,不响应取消的任务函数仍占用执行名额
 func (t *Task) Run(callback func(code int64, msg string)) {
-	defer func(cancel func()) {
+	defer t.Cancel()
+	//任务函数的span, 其上下文作为Ext传入任务函数
+	var span trace.Span
+	t.Ext, span = t.tracer.Start(t.Ext, "xxl.task "+t.Name, trace.WithAttributes(runAttributes(t.Param)...))
+	result := make(chan taskResult, 1)
+	go t.call(span, result)
+
+	select {
+	case r := <-result:
+		callback(r.code, r.msg)
+		return
+	case <-t.Ext.Done():
+	}
+	if t.Ext.Err() != context.DeadlineExceeded { //被终止或覆盖,等待任务函数返回
+		r := <-result
+		callback(r.code, r.msg)
+		return
+	}
+
+	atomic.StoreInt32(&t.abandoned, 1)
+	if t.abandonedRuns != nil {
+		atomic.AddInt64(t.abandonedRuns, 1)
+		defer atomic.AddInt64(t.abandonedRuns, -1)
+	}
+	t.metrics.timeout(t.pattern)
+	t.log.Warn(t.lang.Text(MsgTaskTimeout), runFields(t.Param, "msgCode", MsgTaskTimeout)...)
+	callback(502, t.lang.Msg(MsgTaskTimeout))
+	r := <-result
+	t.log.Warn(t.lang.Text(MsgTaskAbandoned), runFields(t.Param, "msgCode", MsgTaskAbandoned, "code", r.code, "msg", r.msg)...)
+}
+
+//执行任务函数
+func (t *Task) call(span trace.Span, result chan<- taskResult) {
+	defer span.End()
+	defer func() {
 		if err := recover(); err != nil {
-			t.log.Info(t.Info()+" panic: %v", err)
-			debug.PrintStack() //堆栈跟踪
-			callback(500, "task panic:"+fmt.Sprintf("%v", err))
-			cancel()
+			atomic.StoreInt32(&t.panicked, 1)
+			t.log.Error(t.lang.Text(MsgTaskPanic), runFields(t.Param, "msgCode", MsgTaskPanic, "err", err, "stack", string(debug.Stack()))...)
+			t.metrics.panic(t.pattern)
+			span.RecordError(fmt.Errorf("task panic: %v", err), trace.WithStackTrace(true))
+			span.SetStatus(codes.Error, "task panic")
+			result <- taskResult{500, t.lang.Msgf(MsgTaskPanic, fmt.Sprint(err))}
 		}
-	}(t.Cancel)
-	msg := t.fn(t.Ext, t.Param)
-	callback(200, msg)
-	return
+	}()
+	r := t.fn(t.Ext, t.Param)
+	if r.Code == 0 {
+		r.Code = 200
+	}
+	result <- taskResult{r.Code, r.Msg}
+}
+
+//超时后是否已放弃等待任务函数
+func (t *Task) Abandoned() bool {
+	return atomic.LoadInt32(&t.abandoned) == 1
+}
+
+//任务函数是否panic
+func (t *Task) Panicked() bool {
+	return atomic.LoadInt32(&t.panicked) == 1
 }
 
 //任务信息
 func (t *Task) Info() string {
-	return "任务ID[" + Int64ToStr(t.Id) + "]任务名称[" + t.Name + "]参数：" + t.Param.ExecutorParams
+	return fmt.Sprintf(t.lang.Text(MsgTaskInfo), t.Id, t.Name, t.Param.ExecutorParams)
 }
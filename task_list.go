@@ -1,6 +1,9 @@
 package xxl
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 //任务列表 [JobID]执行函数,并行执行时[+LogID]
 type taskList struct {
@@ -38,11 +41,27 @@ func (t *taskList) Del(key string) {
 
 //长度
 func (t *taskList) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return len(t.data)
 }
 
 //Key是否存在
 func (t *taskList) Exists(key string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	_, ok := t.data[key]
 	return ok
 }
+
+//获取所有key,已排序
+func (t *taskList) Keys() []string {
+	t.mu.RLock()
+	keys := make([]string, 0, len(t.data))
+	for k := range t.data {
+		keys = append(keys, k)
+	}
+	t.mu.RUnlock()
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package xxl
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestTaskTimeoutFor(t *testing.T) {
+	cases := []struct {
+		name            string
+		timeout, max    time.Duration
+		executorTimeout int64
+		want            time.Duration
+	}{
+		{"none", 0, 0, 0, 0},
+		{"request", 0, 0, 3, 3 * time.Second},
+		{"default", time.Second, 0, 0, time.Second},
+		{"request over default", time.Second, 0, 3, 3 * time.Second},
+		{"max caps request", 0, 2 * time.Second, 3, 2 * time.Second},
+		{"max caps unlimited", 0, 2 * time.Second, 0, 2 * time.Second},
+		{"under max", time.Second, 2 * time.Second, 0, time.Second},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			task := &Task{timeout: c.timeout, maxTimeout: c.max}
+			assert.Equal(t, c.want, task.timeoutFor(&RunReq{ExecutorTimeout: c.executorTimeout}))
+		})
+	}
+}
+
+func TestTaskTimeoutCallback(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), Language(LangEn), MaxConcurrency(1))
+	e.Init()
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	e.RegTask("task.hung", func(cxt context.Context, param *RunReq) string {
+		defer close(finished)
+		<-release //不检查cxt
+		return "late"
+	}, TaskTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	r := trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.hung"})
+	assert.Equal(t, int64(200), r.ExecuteResult.Code)
+	select {
+	case c := <-callbacks:
+		assert.Equal(t, int64(502), c.ExecuteResult.Code)
+		assert.Assert(t, strings.HasPrefix(c.ExecuteResult.Msg.(string), "[TASK_TIMEOUT]"))
+		assert.Assert(t, time.Since(start) < time.Second)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no timeout callback")
+	}
+	waitFor(t, func() bool { return !e.runList.Exists("1") })
+	//任务函数返回前仍占用执行名额
+	s := e.Concurrency()
+	assert.Equal(t, 1, s.Running)
+	assert.Equal(t, 1, s.Abandoned)
+	r = trigger(e, &RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.hung"})
+	assert.Assert(t, strings.HasPrefix(r.ExecuteResult.Msg.(string), "[EXECUTOR_BUSY]"))
+
+	close(release)
+	<-finished
+	waitFor(t, func() bool { s := e.Concurrency(); return s.Running == 0 && s.Abandoned == 0 })
+	select {
+	case c := <-callbacks:
+		t.Fatalf("unexpected second callback %+v", c.ExecuteResult)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTaskKillNotTimeout(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL))
+	e.Init()
+	e.RegTask("task.ctx", func(cxt context.Context, param *RunReq) string {
+		<-cxt.Done()
+		return "stopped"
+	}, TaskTimeout(time.Minute))
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.ctx"})
+	task := e.runList.Get("1")
+	task.Cancel()
+	c := <-callbacks
+	assert.Equal(t, int64(200), c.ExecuteResult.Code)
+	assert.Equal(t, "stopped", c.ExecuteResult.Msg)
+	assert.Assert(t, !task.Abandoned())
+}
@@ -0,0 +1,191 @@
+package xxl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+//测试证书
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+//生成证书,parent为nil时生成自签名CA
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	assert.NilError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	dir := t.TempDir()
+	c := &testCert{cert: cert, key: key, certFile: filepath.Join(dir, name+".pem"), keyFile: filepath.Join(dir, name+"-key.pem")}
+	assert.NilError(t, ioutil.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NilError(t, ioutil.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return c
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	serverCert := newTestCert(t, "server", ca)
+	clientCert := newTestCert(t, "client", ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	//调度中心要求客户端证书
+	registry := make(chan *Registry, 1)
+	admin := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &Registry{}
+		_ = json.NewDecoder(r.Body).Decode(req)
+		select {
+		case registry <- req:
+		default:
+		}
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	adminCert, err := tls.LoadX509KeyPair(serverCert.certFile, serverCert.keyFile)
+	assert.NilError(t, err)
+	admin.TLS = &tls.Config{Certificates: []tls.Certificate{adminCert}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	admin.StartTLS()
+	defer admin.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	e := newExecutor(
+		ServerAddr(admin.URL),
+		ExecutorIp("127.0.0.1"),
+		Listener(l),
+		TLS(serverCert.certFile, serverCert.keyFile),
+		TLSClientCA(ca.certFile),
+		AdminCA(ca.certFile),
+		AdminClientCert(clientCert.certFile, clientCert.keyFile),
+	)
+	e.Init()
+	done := make(chan error, 1)
+	go func() { done <- e.Run() }()
+	defer func() {
+		_ = l.Close()
+		<-done
+	}()
+
+	select {
+	case req := <-registry:
+		assert.Equal(t, "https://"+l.Addr().String(), req.RegistryValue)
+	case <-time.After(2 * time.Second):
+		t.Fatal("registry not received")
+	}
+
+	//带客户端证书可以访问执行器
+	key, err := tls.LoadX509KeyPair(clientCert.certFile, clientCert.keyFile)
+	assert.NilError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{key}}}}
+	resp, err := client.Get("https://" + l.Addr().String() + "/healthz")
+	assert.NilError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	//没有客户端证书被拒绝
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err = client.Get("https://" + l.Addr().String() + "/healthz")
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+	assert.Assert(t, err != nil)
+}
+
+func TestTLSInitError(t *testing.T) {
+	e := newExecutor(TLS("missing.pem", "missing-key.pem"))
+	e.Init()
+	assert.ErrorContains(t, e.Run(), "load executor certificate")
+}
+
+func TestTLSClientCAWithoutCert(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	e := newExecutor(ServerAddr("http://127.0.0.1:1"), TLSClientCA(ca.certFile))
+	e.Init()
+	assert.ErrorContains(t, e.Run(), "TLSClientCA requires")
+}
+
+func TestTLSClientCAWithServer(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	serverCert := newTestCert(t, "server", ca)
+	clientCert := newTestCert(t, "client", ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+	}))
+	defer admin.Close()
+
+	cert, err := tls.LoadX509KeyPair(serverCert.certFile, serverCert.keyFile)
+	assert.NilError(t, err)
+	server := &http.Server{TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}}}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	e := newExecutor(ServerAddr(admin.URL), ExecutorIp("127.0.0.1"), Listener(l), HTTPServer(server), TLSClientCA(ca.certFile))
+	e.Init()
+	assert.NilError(t, e.initErr)
+	//不修改传入的TLSConfig
+	assert.Equal(t, tls.NoClientCert, server.TLSConfig.ClientAuth)
+	done := make(chan error, 1)
+	go func() { done <- e.Run() }()
+	defer func() {
+		_ = l.Close()
+		<-done
+	}()
+
+	get := func(certs ...tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs}}}
+		resp, err := client.Get("https://" + l.Addr().String() + "/healthz")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+	key, err := tls.LoadX509KeyPair(clientCert.certFile, clientCert.keyFile)
+	assert.NilError(t, err)
+	for deadline := time.Now().Add(time.Second); get(key) != nil && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+	}
+	assert.NilError(t, get(key))
+	//没有客户端证书被拒绝
+	assert.Assert(t, get() != nil)
+}
@@ -0,0 +1,60 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//链路追踪名称
+const tracerName = "github.com/konglong87/xxl-job-executor-go"
+
+//链路追踪属性
+const (
+	attrJobID         = attribute.Key("xxl.job_id")
+	attrLogID         = attribute.Key("xxl.log_id")
+	attrHandler       = attribute.Key("xxl.handler")
+	attrBlockStrategy = attribute.Key("xxl.block_strategy")
+	attrCode          = attribute.Key("xxl.code")
+)
+
+//获取tracer,未设置TracerProvider时使用otel全局设置
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+//任务调度span属性
+func runAttributes(param *RunReq) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrJobID.Int64(param.JobID),
+		attrLogID.Int64(param.LogID),
+		attrHandler.String(param.ExecutorHandler),
+		attrBlockStrategy.String(param.ExecutorBlockStrategy),
+	}
+}
+
+//从调度请求中提取上游链路
+func extractTrace(cxt context.Context, request *http.Request) context.Context {
+	return otel.GetTextMapPropagator().Extract(cxt, propagation.HeaderCarrier(request.Header))
+}
+
+//向请求注入链路
+func injectTrace(ctx context.Context, request *http.Request) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+}
+
+//记录任务结果, 非200记为错误
+func spanResult(span trace.Span, code int64, msg string) {
+	span.SetAttributes(attrCode.Int64(code))
+	if code != 200 {
+		span.SetStatus(codes.Error, msg)
+	}
+}
@@ -0,0 +1,83 @@
+package xxl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gotest.tools/assert"
+)
+
+func TestTracing(t *testing.T) {
+	done := make(chan struct{}, 2)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":200,"msg":null}`))
+		if r.URL.Path == "/api/callback" {
+			done <- struct{}{}
+		}
+	}))
+	defer admin.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	e := newExecutor(ServerAddr(admin.URL), TracerProvider(tp))
+	e.Init()
+
+	var taskSpan trace.SpanContext
+	e.RegTask("task.ok", func(cxt context.Context, param *RunReq) string {
+		taskSpan = trace.SpanContextFromContext(cxt)
+		return "ok"
+	})
+	e.RegTask("task.panic", func(cxt context.Context, param *RunReq) string { panic("boom") })
+
+	trigger := func(body string) {
+		w := httptest.NewRecorder()
+		e.runTask(w, httptest.NewRequest("POST", "/run", strings.NewReader(body)))
+	}
+	trigger(`{"jobId":1,"executorHandler":"task.ok","logId":11,"executorBlockStrategy":"SERIAL_EXECUTION"}`)
+	<-done
+	trigger(`{"jobId":2,"executorHandler":"task.panic","logId":12}`)
+	<-done
+	//run span在回调返回后结束
+	for deadline := time.Now().Add(time.Second); len(exporter.GetSpans()) < 6 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, s := range exporter.GetSpans() {
+		spans[s.Name] = s
+	}
+	run := spans["xxl.run task.ok"]
+	task := spans["xxl.task task.ok"]
+	assert.Assert(t, run.SpanContext.IsValid())
+	assert.Equal(t, task.Parent.SpanID(), run.SpanContext.SpanID())
+	assert.Equal(t, taskSpan.SpanID(), task.SpanContext.SpanID())
+	assert.Equal(t, codes.Unset, run.Status.Code)
+
+	attrs := map[string]string{}
+	for _, kv := range run.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "1", attrs["xxl.job_id"])
+	assert.Equal(t, "11", attrs["xxl.log_id"])
+	assert.Equal(t, "task.ok", attrs["xxl.handler"])
+	assert.Equal(t, "SERIAL_EXECUTION", attrs["xxl.block_strategy"])
+
+	var callbacks int
+	for _, s := range exporter.GetSpans() {
+		if s.Name == "xxl.callback" {
+			callbacks++
+		}
+	}
+	assert.Equal(t, 2, callbacks)
+
+	assert.Equal(t, codes.Error, spans["xxl.task task.panic"].Status.Code)
+	assert.Equal(t, codes.Error, spans["xxl.run task.panic"].Status.Code)
+}
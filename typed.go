@@ -0,0 +1,249 @@
+package xxl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+带类型参数的任务,executorParams按以下格式解析到T:
+	{"id":99}              json,T为struct、map、slice时
+	id=99&name=a           key=value,以&、;或换行分隔,key为json名或字段名(不区分大小写),T为struct或map[string]string时
+	99 / abc / a,b         纯字符串,T为string、数字、bool、time.Duration或其切片(逗号分隔)时
+struct字段支持标签:
+	default:"10"           参数中没有该字段时的默认值
+	validate:"required"    解析后不能为零值
+T或*T实现Validator时,解析后调用Validate。解析或校验失败时不执行任务函数,直接回调500
+*/
+
+//带类型参数的任务函数
+type TypedTaskFunc[T any] func(cxt context.Context, params T, param *RunReq) Result
+
+//参数校验
+type Validator interface {
+	Validate() error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//注册带类型参数的任务
+func RegTyped[T any](e Executor, pattern string, fn TypedTaskFunc[T], opts ...TaskOption) {
+	e.RegTask(pattern, nil, append(opts[:len(opts):len(opts)], typedTask(fn))...)
+}
+
+//解析参数后执行任务函数
+func typedTask[T any](fn TypedTaskFunc[T]) TaskOption {
+	return func(t *Task) {
+		t.fn = func(cxt context.Context, param *RunReq) Result {
+			params, err := DecodeParams[T](param.ExecutorParams)
+			if err != nil {
+				return Result{Code: 500, Msg: t.lang.Msgf(MsgParamsDecodeErr, err.Error())}
+			}
+			return fn(cxt, params, param)
+		}
+	}
+}
+
+//按RegTyped的规则解析executorParams
+func DecodeParams[T any](s string) (T, error) {
+	var v T
+	rv := reflect.ValueOf(&v).Elem()
+	if rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		if err := applyDefaults(rv); err != nil {
+			return v, err
+		}
+	}
+	if err := decodeValue(rv, strings.TrimSpace(s)); err != nil {
+		return v, err
+	}
+	if rv.Kind() == reflect.Struct {
+		if err := checkRequired(rv); err != nil {
+			return v, err
+		}
+	}
+	if val, ok := interface{}(v).(Validator); ok {
+		return v, val.Validate()
+	}
+	if val, ok := interface{}(&v).(Validator); ok {
+		return v, val.Validate()
+	}
+	return v, nil
+}
+
+func decodeValue(rv reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map:
+		if s[0] == '{' {
+			return json.Unmarshal([]byte(s), rv.Addr().Interface())
+		}
+		return decodeKV(rv, s)
+	case reflect.Slice:
+		if s[0] == '[' {
+			return json.Unmarshal([]byte(s), rv.Addr().Interface())
+		}
+		return setString(rv, s)
+	case reflect.Interface:
+		return json.Unmarshal([]byte(s), rv.Addr().Interface())
+	}
+	return setString(rv, s)
+}
+
+//解析key=value格式
+func decodeKV(rv reflect.Value, s string) error {
+	if rv.Kind() == reflect.Map {
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported param type %s", rv.Type())
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+	}
+	for _, pair := range strings.FieldsFunc(s, func(r rune) bool { return r == '&' || r == ';' || r == '\n' }) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.IndexByte(pair, '=')
+		if i <= 0 {
+			return fmt.Errorf("invalid param %q, want key=value", pair)
+		}
+		key, val := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if rv.Kind() == reflect.Map {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := setString(elem, val); err != nil {
+				return fmt.Errorf("param %s: %v", key, err)
+			}
+			rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), elem)
+			continue
+		}
+		field, ok := fieldByName(rv, key)
+		if !ok {
+			return fmt.Errorf("unknown param %s", key)
+		}
+		if err := setString(field, val); err != nil {
+			return fmt.Errorf("param %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+//按json名或字段名查找字段
+func fieldByName(rv reflect.Value, key string) (reflect.Value, bool) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if strings.EqualFold(paramName(f), key) || strings.EqualFold(f.Name, key) {
+			return rv.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+//字段参数名,优先json标签
+func paramName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+//设置default标签
+func applyDefaults(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		def, ok := f.Tag.Lookup("default")
+		if !ok || f.PkgPath != "" {
+			continue
+		}
+		if err := setString(rv.Field(i), def); err != nil {
+			return fmt.Errorf("default of %s: %v", paramName(f), err)
+		}
+	}
+	return nil
+}
+
+//检查validate:"required"
+func checkRequired(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if f.Tag.Get("validate") == "required" && f.PkgPath == "" && rv.Field(i).IsZero() {
+			return errors.New("param " + paramName(f) + " is required")
+		}
+	}
+	return nil
+}
+
+//字符串转换为字段类型
+func setString(rv reflect.Value, s string) error {
+	if rv.Type() == durationType {
+		d, err := parseTimeout(s)
+		if err != nil {
+			return err
+		}
+		rv.SetInt(int64(d))
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		rv.SetFloat(n)
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		sl := reflect.MakeSlice(rv.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setString(sl.Index(i), strings.TrimSpace(p)); err != nil {
+				return err
+			}
+		}
+		rv.Set(sl)
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return setString(rv.Elem(), s)
+	default:
+		return fmt.Errorf("unsupported param type %s", rv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package xxl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type typedParams struct {
+	ID      int64         `json:"id" validate:"required"`
+	Name    string        `json:"name" default:"all"`
+	Retry   int           `json:"retry" default:"3"`
+	DryRun  bool          `json:"dry_run"`
+	Timeout time.Duration `json:"timeout" default:"30s"`
+	Tags    []string      `json:"tags"`
+}
+
+func (p typedParams) Validate() error {
+	if p.Retry < 0 {
+		return errors.New("retry must not be negative")
+	}
+	return nil
+}
+
+func TestDecodeParams(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want typedParams
+		err  string
+	}{
+		{"json", `{"id":99,"tags":["a"]}`, typedParams{ID: 99, Name: "all", Retry: 3, Timeout: 30 * time.Second, Tags: []string{"a"}}, ""},
+		{"kv", "id=99&name=orders&dry_run=true&timeout=5&tags=a,b", typedParams{ID: 99, Name: "orders", Retry: 3, DryRun: true, Timeout: 5 * time.Second, Tags: []string{"a", "b"}}, ""},
+		{"kv lines", "ID = 7\nretry=1", typedParams{ID: 7, Name: "all", Retry: 1, Timeout: 30 * time.Second}, ""},
+		{"required", "", typedParams{}, "param id is required"},
+		{"validate", "id=1;retry=-1", typedParams{}, "retry must not be negative"},
+		{"bad int", "id=x", typedParams{}, `param id: invalid integer "x"`},
+		{"unknown", "id=1&foo=bar", typedParams{}, "unknown param foo"},
+		{"plain", "hello", typedParams{}, `invalid param "hello", want key=value`},
+		{"bad json", `{"id":"x"}`, typedParams{}, "json: cannot unmarshal"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := DecodeParams[typedParams](c.in)
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, c.want, got)
+		})
+	}
+}
+
+func TestDecodeParamsScalar(t *testing.T) {
+	s, err := DecodeParams[string](" hello ")
+	assert.NilError(t, err)
+	assert.Equal(t, "hello", s)
+
+	n, err := DecodeParams[int](`99`)
+	assert.NilError(t, err)
+	assert.Equal(t, 99, n)
+
+	ids, err := DecodeParams[[]int64]("1, 2,3")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []int64{1, 2, 3}, ids)
+
+	m, err := DecodeParams[map[string]string]("a=1&b=2")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]string{"a": "1", "b": "2"}, m)
+
+	p, err := DecodeParams[*typedParams]("id=1")
+	assert.NilError(t, err)
+	assert.Equal(t, "all", p.Name)
+
+	_, err = DecodeParams[bool]("maybe")
+	assert.ErrorContains(t, err, `invalid bool "maybe"`)
+}
+
+func TestRegTyped(t *testing.T) {
+	admin, callbacks := newTestAdmin(t)
+	e := newExecutor(ServerAddr(admin.URL), Language(LangEn))
+	e.Init()
+	called := make(chan typedParams, 1)
+	RegTyped(e, "task.typed", func(cxt context.Context, params typedParams, param *RunReq) Result {
+		called <- params
+		return Result{Msg: "sync " + params.Name}
+	})
+
+	trigger(e, &RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.typed", ExecutorParams: `{"id":99}`})
+	c := <-callbacks
+	assert.Equal(t, int64(200), c.ExecuteResult.Code)
+	assert.Equal(t, "sync all", c.ExecuteResult.Msg)
+	assert.Equal(t, int64(99), (<-called).ID)
+
+	trigger(e, &RunReq{JobID: 2, LogID: 2, ExecutorHandler: "task.typed", ExecutorParams: "id=abc"})
+	c = <-callbacks
+	assert.Equal(t, int64(500), c.ExecuteResult.Code)
+	assert.Assert(t, strings.HasPrefix(c.ExecuteResult.Msg.(string), `[PARAMS_DECODE_ERR] invalid task params: param id: invalid integer "abc"`))
+	assert.Equal(t, 0, len(called))
+}
@@ -33,11 +33,17 @@ func returnCall(req *RunReq, code int64, msg string) []byte {
 }
 
 //杀死任务返回
-func returnKill(req *killReq, code int64) []byte {
-	msg := ""
-	if code != 200 {
-		msg = "Task kill err"
+func returnKill(req *killReq, code int64, msg string) []byte {
+	data := res{
+		Code: code,
+		Msg:  msg,
 	}
+	str, _ := json.Marshal(data)
+	return str
+}
+
+//忙碌检测返回
+func returnIdleBeat(req *idleBeatReq, code int64, msg string) []byte {
 	data := res{
 		Code: code,
 		Msg:  msg,
@@ -0,0 +1,427 @@
+/*
+Package xxltest 基于httptest的调度中心,用于测试注册、回调、动态任务,不需要真实的xxl-job-admin:
+
+	admin := xxltest.NewAdmin(t)
+	exec := xxl.NewExecutor(xxl.ServerAddr(admin.URL))
+	exec.Init()
+	exec.RegTask("task.test", task.Test)
+	cb, err := admin.RunAndWait(exec.Handler(), &xxl.RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.test"}, time.Second)
+
+支持/api/registry、/api/registryRemove、/api/callback、/jobinfo/*、/login,记录收到的全部请求,
+可通过Inject注入失败与延迟
+*/
+package xxltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+)
+
+//登录成功后返回的cookie名称
+const LoginCookie = "XXL_JOB_LOGIN_IDENTITY"
+
+//调度中心收到的请求
+type Request struct {
+	Method string      // 请求方法
+	Path   string      // 请求路径
+	Header http.Header // 请求头
+	Body   []byte      // 请求内容
+	Time   time.Time   // 收到时间
+}
+
+//执行器回调的任务结果
+type Callback struct {
+	LogID         int64             `json:"logId"`
+	LogDateTim    int64             `json:"logDateTim"`
+	ExecuteResult xxl.ExecuteResult `json:"executeResult"`
+}
+
+//结果说明
+func (c Callback) Msg() string {
+	if c.ExecuteResult.Msg == nil {
+		return ""
+	}
+	if s, ok := c.ExecuteResult.Msg.(string); ok {
+		return s
+	}
+	str, _ := json.Marshal(c.ExecuteResult.Msg)
+	return string(str)
+}
+
+//通过/jobinfo/add添加的任务
+type Job struct {
+	ID      int               // 任务ID,从1开始递增
+	Params  map[string]string // 添加任务的参数,json与表单参数都转为字符串
+	Running bool              // 是否已通过/jobinfo/start启动
+}
+
+//注入的故障
+type Fault struct {
+	Status  int           // http状态码,为0且Body为空时只增加延迟,正常处理请求
+	Body    string        // 响应内容,为空时为{"code":500,"msg":"xxltest fault"}
+	Latency time.Duration // 响应前等待的时间
+	Times   int           // 生效次数,0为一直生效
+}
+
+//模拟的调度中心
+type Admin struct {
+	*httptest.Server
+	AccessToken string // 不为空时校验XXL-JOB-ACCESS-TOKEN,需在执行器请求前设置
+	UserName    string // 不为空时校验/login的用户名密码
+	Password    string
+
+	mu        sync.Mutex
+	changed   chan struct{} //收到请求时关闭并重建,用于等待
+	requests  []Request
+	registry  map[string]xxl.Registry //当前注册的执行器,key为registryKey+registryValue
+	removed   []xxl.Registry
+	callbacks []Callback
+	jobs      []*Job
+	faults    map[string]*Fault
+}
+
+//创建调度中心,测试结束时关闭
+func NewAdmin(tb testing.TB) *Admin {
+	a := NewUnstartedAdmin()
+	a.Start()
+	tb.Cleanup(a.Close)
+	return a
+}
+
+//创建未启动的调度中心,需调用Start,用完后调用Close
+func NewUnstartedAdmin() *Admin {
+	a := &Admin{
+		changed:  make(chan struct{}),
+		registry: make(map[string]xxl.Registry),
+		faults:   make(map[string]*Fault),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/registry", a.apiRegistry)
+	mux.HandleFunc("/api/registryRemove", a.apiRegistryRemove)
+	mux.HandleFunc("/api/callback", a.apiCallback)
+	mux.HandleFunc("/jobinfo/", a.jobInfo)
+	mux.HandleFunc("/login", a.login)
+	a.Server = httptest.NewUnstartedServer(a.intercept(mux))
+	return a
+}
+
+//注入故障,path为请求路径,*为全部请求;再次注入时覆盖
+func (a *Admin) Inject(path string, f Fault) {
+	a.mu.Lock()
+	a.faults[path] = &f
+	a.mu.Unlock()
+}
+
+//清除注入的故障,不传path时清除全部
+func (a *Admin) Reset(paths ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(paths) == 0 {
+		a.faults = make(map[string]*Fault)
+		return
+	}
+	for _, p := range paths {
+		delete(a.faults, p)
+	}
+}
+
+//收到的全部请求
+func (a *Admin) Requests() []Request {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Request(nil), a.requests...)
+}
+
+//收到的指定路径的请求
+func (a *Admin) RequestsTo(path string) []Request {
+	var list []Request
+	for _, r := range a.Requests() {
+		if r.Path == path {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+//当前注册的执行器
+func (a *Admin) Registered() []xxl.Registry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]xxl.Registry, 0, len(a.registry))
+	for _, r := range a.registry {
+		list = append(list, r)
+	}
+	return list
+}
+
+//已摘除的执行器
+func (a *Admin) Removed() []xxl.Registry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]xxl.Registry(nil), a.removed...)
+}
+
+//收到的全部回调
+func (a *Admin) Callbacks() []Callback {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Callback(nil), a.callbacks...)
+}
+
+//添加的任务
+func (a *Admin) Jobs() []Job {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]Job, 0, len(a.jobs))
+	for _, j := range a.jobs {
+		list = append(list, *j)
+	}
+	return list
+}
+
+//等待满足条件,超时返回false
+func (a *Admin) Wait(timeout time.Duration, cond func(a *Admin) bool) bool {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for {
+		a.mu.Lock()
+		changed := a.changed
+		a.mu.Unlock()
+		if cond(a) {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-t.C:
+			return cond(a)
+		}
+	}
+}
+
+//等待执行器注册
+func (a *Admin) WaitRegistered(registryKey string, timeout time.Duration) (xxl.Registry, bool) {
+	var reg xxl.Registry
+	ok := a.Wait(timeout, func(a *Admin) bool {
+		for _, r := range a.Registered() {
+			if r.RegistryKey == registryKey {
+				reg = r
+				return true
+			}
+		}
+		return false
+	})
+	return reg, ok
+}
+
+//等待logID的回调
+func (a *Admin) WaitCallback(logID int64, timeout time.Duration) (Callback, bool) {
+	var cb Callback
+	ok := a.Wait(timeout, func(a *Admin) bool {
+		for _, c := range a.Callbacks() {
+			if c.LogID == logID {
+				cb = c
+				return true
+			}
+		}
+		return false
+	})
+	return cb, ok
+}
+
+//记录请求并处理注入的故障
+func (a *Admin) intercept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		a.record(func() {
+			a.requests = append(a.requests, Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body, Time: time.Now()})
+		})
+
+		if f, ok := a.fault(r.URL.Path); ok {
+			if f.Latency > 0 {
+				select {
+				case <-time.After(f.Latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			if f.Status != 0 || f.Body != "" {
+				if f.Status == 0 {
+					f.Status = http.StatusOK
+				}
+				if f.Body == "" {
+					f.Body = `{"code":500,"msg":"xxltest fault"}`
+				}
+				w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+				w.WriteHeader(f.Status)
+				_, _ = io.WriteString(w, f.Body)
+				return
+			}
+		}
+		if strings.HasPrefix(r.URL.Path, "/api/") && a.AccessToken != "" && r.Header.Get("XXL-JOB-ACCESS-TOKEN") != a.AccessToken {
+			writeRes(w, 500, "The access token is wrong.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//取出生效的故障,按次数生效的故障减少一次
+func (a *Admin) fault(path string) (Fault, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, key := range []string{path, "*"} {
+		f, ok := a.faults[key]
+		if !ok {
+			continue
+		}
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				delete(a.faults, key)
+			}
+		}
+		return *f, true
+	}
+	return Fault{}, false
+}
+
+//修改记录并通知等待者
+func (a *Admin) record(fn func()) {
+	a.mu.Lock()
+	fn()
+	close(a.changed)
+	a.changed = make(chan struct{})
+	a.mu.Unlock()
+}
+
+func (a *Admin) apiRegistry(w http.ResponseWriter, r *http.Request) {
+	reg := xxl.Registry{}
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		writeRes(w, 500, err.Error())
+		return
+	}
+	a.record(func() {
+		a.registry[reg.RegistryKey+"\n"+reg.RegistryValue] = reg
+	})
+	writeRes(w, 200, nil)
+}
+
+func (a *Admin) apiRegistryRemove(w http.ResponseWriter, r *http.Request) {
+	reg := xxl.Registry{}
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		writeRes(w, 500, err.Error())
+		return
+	}
+	a.record(func() {
+		delete(a.registry, reg.RegistryKey+"\n"+reg.RegistryValue)
+		a.removed = append(a.removed, reg)
+	})
+	writeRes(w, 200, nil)
+}
+
+func (a *Admin) apiCallback(w http.ResponseWriter, r *http.Request) {
+	var list []Callback
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		writeRes(w, 500, err.Error())
+		return
+	}
+	a.record(func() {
+		a.callbacks = append(a.callbacks, list...)
+	})
+	writeRes(w, 200, nil)
+}
+
+//任务管理,支持add、start、stop,其他路径只记录请求
+func (a *Admin) jobInfo(w http.ResponseWriter, r *http.Request) {
+	params, err := readParams(r)
+	if err != nil {
+		writeRes(w, 500, err.Error())
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, "/jobinfo/") {
+	case "add":
+		var id int
+		a.record(func() {
+			id = len(a.jobs) + 1
+			a.jobs = append(a.jobs, &Job{ID: id, Params: params})
+		})
+		writeJSON(w, map[string]interface{}{"code": 200, "msg": nil, "content": strconv.Itoa(id)})
+	case "start", "stop":
+		id, _ := strconv.Atoi(params["id"])
+		found := false
+		a.record(func() {
+			if id > 0 && id <= len(a.jobs) {
+				a.jobs[id-1].Running = strings.HasSuffix(r.URL.Path, "start")
+				found = true
+			}
+		})
+		if !found {
+			writeRes(w, 500, "job not found")
+			return
+		}
+		writeRes(w, 200, nil)
+	default:
+		writeRes(w, 200, nil)
+	}
+}
+
+func (a *Admin) login(w http.ResponseWriter, r *http.Request) {
+	params, _ := readParams(r)
+	if a.UserName != "" && (params["userName"] != a.UserName || params["password"] != a.Password) {
+		writeRes(w, 500, "账号或密码错误")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: LoginCookie, Value: "xxltest", Path: "/"})
+	writeRes(w, 200, nil)
+}
+
+//读取表单或json参数
+func readParams(r *http.Request) (map[string]string, error) {
+	params := make(map[string]string)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		m := make(map[string]interface{})
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			return nil, err
+		}
+		for k, v := range m {
+			switch v := v.(type) {
+			case string:
+				params[k] = v
+			case nil:
+				params[k] = ""
+			default:
+				str, _ := json.Marshal(v)
+				params[k] = string(str)
+			}
+		}
+		return params, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	for k := range r.Form {
+		params[k] = r.Form.Get(k)
+	}
+	return params, nil
+}
+
+func writeRes(w http.ResponseWriter, code int64, msg interface{}) {
+	writeJSON(w, map[string]interface{}{"code": code, "msg": msg})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	str, _ := json.Marshal(v)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	_, _ = w.Write(str)
+}
@@ -0,0 +1,175 @@
+package xxltest_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+	"github.com/konglong87/xxl-job-executor-go/xxltest"
+	"gotest.tools/assert"
+)
+
+func newExecutor(admin *xxltest.Admin, opts ...xxl.Option) xxl.Executor {
+	exec := xxl.NewExecutor(append([]xxl.Option{xxl.ServerAddr(admin.URL), xxl.Language(xxl.LangEn)}, opts...)...)
+	exec.Init()
+	return exec
+}
+
+func TestRegistry(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	admin.AccessToken = "secret"
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	exec := newExecutor(admin, xxl.AccessToken("secret"), xxl.RegistryKey("xxltest"),
+		xxl.Listener(l), xxl.AdvertiseAddr("http://"+l.Addr().String()))
+	exec.RegTask("task.echo", func(cxt context.Context, param *xxl.RunReq) string { return param.ExecutorParams })
+	done := make(chan error, 1)
+	go func() { done <- exec.Run() }()
+
+	reg, ok := admin.WaitRegistered("xxltest", 2*time.Second)
+	assert.Assert(t, ok)
+	assert.Equal(t, "EXECUTOR", reg.RegistryGroup)
+	assert.Equal(t, "secret", reg.AccessToken)
+
+	//通过注册地址调度
+	cb, err := admin.RunAndWait(xxltest.Remote(reg.RegistryValue), &xxl.RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.echo", ExecutorParams: "hi"}, 2*time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(200), cb.ExecuteResult.Code)
+	assert.Equal(t, "hi", cb.Msg())
+
+	_ = l.Close()
+	<-done
+	assert.Assert(t, admin.Wait(2*time.Second, func(a *xxltest.Admin) bool { return len(a.Removed()) == 1 }))
+	assert.Equal(t, 0, len(admin.Registered()))
+}
+
+func TestWrongAccessToken(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	admin.AccessToken = "secret"
+	exec := newExecutor(admin, xxl.AccessToken("wrong"))
+	exec.RegTask("task.echo", func(cxt context.Context, param *xxl.RunReq) string { return "ok" })
+
+	_, err := admin.RunAndWait(exec.Handler(), &xxl.RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.echo"}, 200*time.Millisecond)
+	assert.ErrorContains(t, err, "no callback")
+	assert.Equal(t, 1, len(admin.RequestsTo("/api/callback")))
+	assert.Equal(t, 0, len(admin.Callbacks()))
+}
+
+func TestRunKillLog(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	exec := newExecutor(admin)
+	exec.LogHandler(func(req *xxl.LogReq) *xxl.LogRes {
+		return &xxl.LogRes{Code: 200, Content: xxl.LogResContent{FromLineNum: req.FromLineNum, ToLineNum: req.FromLineNum, LogContent: "log " + xxl.Int64ToStr(req.LogID), IsEnd: true}}
+	})
+	started := make(chan struct{}, 1)
+	exec.RegTask("task.wait", func(cxt context.Context, param *xxl.RunReq) string {
+		started <- struct{}{}
+		<-cxt.Done()
+		return "stopped"
+	})
+
+	res, err := admin.Run(exec.Handler(), &xxl.RunReq{JobID: 1, LogID: 11, ExecutorHandler: "task.missing"})
+	assert.NilError(t, err)
+	assert.Equal(t, int64(500), res.Code)
+	cb, err := admin.RunAndWait(exec.Handler(), &xxl.RunReq{JobID: 1, LogID: 11, ExecutorHandler: "task.missing"}, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(500), cb.ExecuteResult.Code)
+
+	res, err = admin.Run(exec.Handler(), &xxl.RunReq{JobID: 2, LogID: 12, ExecutorHandler: "task.wait"})
+	assert.NilError(t, err)
+	assert.Equal(t, int64(200), res.Code)
+	<-started
+	res, err = admin.Kill(exec.Handler(), 2)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(200), res.Code)
+	cb, ok := admin.WaitCallback(12, time.Second)
+	assert.Assert(t, ok)
+	assert.Equal(t, "stopped", cb.Msg())
+	res, err = admin.Kill(exec.Handler(), 2)
+	assert.NilError(t, err)
+	assert.Equal(t, int64(500), res.Code)
+
+	logRes, err := admin.Log(exec.Handler(), &xxl.LogReq{LogID: 12})
+	assert.NilError(t, err)
+	assert.Equal(t, "log 12", logRes.Content.LogContent)
+	assert.Equal(t, 1, logRes.Content.FromLineNum)
+}
+
+func TestInject(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	exec := newExecutor(admin)
+	exec.RegTask("task.echo", func(cxt context.Context, param *xxl.RunReq) string { return param.ExecutorParams })
+
+	//回调失败一次
+	admin.Inject("/api/callback", xxltest.Fault{Status: http.StatusInternalServerError, Times: 1})
+	_, err := admin.RunAndWait(exec.Handler(), &xxl.RunReq{JobID: 1, LogID: 1, ExecutorHandler: "task.echo"}, 200*time.Millisecond)
+	assert.ErrorContains(t, err, "no callback")
+	assert.Equal(t, 1, len(admin.RequestsTo("/api/callback")))
+
+	//延迟
+	admin.Inject("*", xxltest.Fault{Latency: 100 * time.Millisecond})
+	start := time.Now()
+	cb, err := admin.RunAndWait(exec.Handler(), &xxl.RunReq{JobID: 1, LogID: 2, ExecutorHandler: "task.echo", ExecutorParams: "slow"}, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, "slow", cb.Msg())
+	assert.Assert(t, time.Since(start) >= 100*time.Millisecond)
+
+	admin.Reset()
+	admin.Inject("/jobinfo/start", xxltest.Fault{Body: `{"code":500,"msg":"busy"}`})
+	body, err := exec.StartJob("1")
+	assert.ErrorContains(t, err, "code 500, msg busy")
+	assert.Equal(t, `{"code":500,"msg":"busy"}`, string(body))
+	assert.Equal(t, 1, len(admin.Callbacks()))
+}
+
+func TestJobInfo(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	exec := newExecutor(admin)
+	info := xxl.AddJobInfo{JobGroupID: 2, JobDesc: "json", ExecutorHandler: "task.a", ExecutorParams: `{"id":1}`}
+
+	body, err := exec.AddJob(info)
+	assert.NilError(t, err)
+	assert.Equal(t, `{"code":200,"content":"1","msg":null}`, string(body))
+	info.JobDesc = "form"
+	_, err = exec.AddJobByPostForm(info)
+	assert.NilError(t, err)
+	body, err = exec.StartJob("2")
+	assert.NilError(t, err)
+	assert.Equal(t, `{"code":200,"msg":null}`, string(body))
+	_, err = exec.StartJob("3")
+	adminErr := &xxl.AdminError{}
+	assert.Assert(t, errors.As(err, &adminErr))
+	assert.Equal(t, int64(500), adminErr.Code)
+	assert.Equal(t, "job not found", adminErr.Msg)
+
+	jobs := admin.Jobs()
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, "json", jobs[0].Params["jobDesc"])
+	assert.Equal(t, "2", jobs[0].Params["jobGroup"])
+	assert.Equal(t, `{"id":1}`, jobs[0].Params["executorParam"])
+	assert.Assert(t, !jobs[0].Running)
+	assert.Equal(t, "form", jobs[1].Params["jobDesc"])
+	assert.Equal(t, "task.a", jobs[1].Params["executorHandler"])
+	assert.Assert(t, jobs[1].Running)
+}
+
+func TestLogin(t *testing.T) {
+	admin := xxltest.NewAdmin(t)
+	admin.UserName, admin.Password = "admin", "123456"
+
+	res, err := http.PostForm(admin.URL+"/login", url.Values{"userName": {"admin"}, "password": {"bad"}})
+	assert.NilError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, 0, len(res.Cookies()))
+
+	res, err = http.PostForm(admin.URL+"/login", url.Values{"userName": {"admin"}, "password": {"123456"}})
+	assert.NilError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, xxltest.LoginCookie, res.Cookies()[0].Name)
+	assert.Equal(t, 2, len(admin.RequestsTo("/login")))
+}
@@ -0,0 +1,134 @@
+package xxltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	xxl "github.com/konglong87/xxl-job-executor-go"
+)
+
+//执行器/run、/kill的响应
+type Response struct {
+	Code int64  // 200 表示正常、其他失败
+	Msg  string // 错误提示消息
+	Body []byte // 响应内容
+}
+
+//运行中的执行器,addr为执行器地址如http://127.0.0.1:9999,可用于调度Admin.Registered()中的执行器
+func Remote(addr string) http.Handler {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		})
+	}
+	return httputil.NewSingleHostReverseProxy(u)
+}
+
+//调度任务,与调度中心一样请求执行器/run,executor一般为exec.Handler()
+func (a *Admin) Run(executor http.Handler, req *xxl.RunReq) (*Response, error) {
+	body, err := a.run(executor, req)
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponse(body)
+}
+
+//调度任务并等待回调;被拒绝时执行器直接返回回调格式的结果,不等待
+func (a *Admin) RunAndWait(executor http.Handler, req *xxl.RunReq, timeout time.Duration) (Callback, error) {
+	body, err := a.run(executor, req)
+	if err != nil {
+		return Callback{}, err
+	}
+	var list []Callback
+	if json.Unmarshal(body, &list) == nil && len(list) > 0 {
+		return list[0], nil
+	}
+	cb, ok := a.WaitCallback(req.LogID, timeout)
+	if !ok {
+		return cb, fmt.Errorf("xxltest: no callback for logId %d in %s", req.LogID, timeout)
+	}
+	return cb, nil
+}
+
+func (a *Admin) run(executor http.Handler, req *xxl.RunReq) ([]byte, error) {
+	if req.LogDateTime == 0 {
+		req.LogDateTime = time.Now().UnixMilli()
+	}
+	if req.GlueType == "" {
+		req.GlueType = "BEAN"
+	}
+	return a.call(executor, "/run", req)
+}
+
+//终止任务
+func (a *Admin) Kill(executor http.Handler, jobID int64) (*Response, error) {
+	body, err := a.call(executor, "/kill", map[string]int64{"jobId": jobID})
+	if err != nil {
+		return nil, err
+	}
+	return decodeResponse(body)
+}
+
+//查询任务日志
+func (a *Admin) Log(executor http.Handler, req *xxl.LogReq) (*xxl.LogRes, error) {
+	if req.FromLineNum <= 0 {
+		req.FromLineNum = 1
+	}
+	body, err := a.call(executor, "/log", req)
+	if err != nil {
+		return nil, err
+	}
+	res := &xxl.LogRes{}
+	if err = json.Unmarshal(body, res); err != nil {
+		return nil, fmt.Errorf("xxltest: /log: %s", body)
+	}
+	return res, nil
+}
+
+//请求执行器,携带AccessToken
+func (a *Admin) call(executor http.Handler, path string, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	request := httptest.NewRequest("POST", path, bytes.NewReader(data))
+	request.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	if a.AccessToken != "" {
+		request.Header.Set("XXL-JOB-ACCESS-TOKEN", a.AccessToken)
+	}
+	w := httptest.NewRecorder()
+	executor.ServeHTTP(w, request)
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("xxltest: %s: %d %s", path, w.Code, w.Body.String())
+	}
+	return w.Body.Bytes(), nil
+}
+
+//解析/run、/kill的响应,失败时执行器返回回调格式的结果
+func decodeResponse(body []byte) (*Response, error) {
+	r := &Response{Body: body}
+	var list []Callback
+	if json.Unmarshal(body, &list) == nil {
+		if len(list) == 0 {
+			return nil, fmt.Errorf("xxltest: empty response")
+		}
+		r.Code, r.Msg = list[0].ExecuteResult.Code, list[0].Msg()
+		return r, nil
+	}
+	var res struct {
+		Code int64       `json:"code"`
+		Msg  interface{} `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("xxltest: %s", body)
+	}
+	r.Code, r.Msg = res.Code, Callback{ExecuteResult: xxl.ExecuteResult{Msg: res.Msg}}.Msg()
+	return r, nil
+}
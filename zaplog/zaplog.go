@@ -0,0 +1,32 @@
+//zap日志适配
+package zaplog
+
+import (
+	xxl "github.com/konglong87/xxl-job-executor-go"
+	"go.uber.org/zap"
+)
+
+//将zap.Logger适配为xxl.StructuredLogger
+func New(l *zap.Logger) xxl.StructuredLogger {
+	return &logger{l: l.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+type logger struct {
+	l *zap.SugaredLogger
+}
+
+func (l *logger) Debug(msg string, kv ...interface{}) {
+	l.l.Debugw(msg, kv...)
+}
+
+func (l *logger) Info(msg string, kv ...interface{}) {
+	l.l.Infow(msg, kv...)
+}
+
+func (l *logger) Warn(msg string, kv ...interface{}) {
+	l.l.Warnw(msg, kv...)
+}
+
+func (l *logger) Error(msg string, kv ...interface{}) {
+	l.l.Errorw(msg, kv...)
+}
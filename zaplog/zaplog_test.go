@@ -0,0 +1,26 @@
+package zaplog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/assert"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := New(zap.New(core))
+	l.Debug("debug")
+	l.Info("info", "jobId", int64(1), "handler", "task.a")
+	l.Warn("warn")
+	l.Error("error", "logId", int64(2))
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, 4, len(entries))
+	assert.Equal(t, zapcore.InfoLevel, entries[1].Level)
+	assert.DeepEqual(t, map[string]interface{}{"jobId": int64(1), "handler": "task.a"}, entries[1].ContextMap())
+	assert.Equal(t, zapcore.ErrorLevel, entries[3].Level)
+	assert.DeepEqual(t, map[string]interface{}{"logId": int64(2)}, entries[3].ContextMap())
+}